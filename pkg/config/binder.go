@@ -0,0 +1,324 @@
+package options
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"time"
+
+	errs2 "github.com/breathbath/go_utils/v3/pkg/errs"
+	"github.com/breathbath/go_utils/v3/pkg/types"
+)
+
+const (
+	paramTag    = "param"
+	defaultTag  = "default"
+	requiredTag = "required"
+	unitTag     = "unit"
+)
+
+var (
+	durationType  = reflect.TypeOf(time.Duration(0))
+	decimalType   = reflect.TypeOf(types.Decimal{})
+	stringSetType = reflect.TypeOf(types.StringSet{})
+	secretType    = reflect.TypeOf(types.Secret{})
+	timeType      = reflect.TypeOf(time.Time{})
+
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+var durationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+}
+
+/*
+Bind fills the struct which target points to with the option values, fields are configured with tags e.g.
+
+	type DBConfig struct {
+		Host    string        `param:"host" default:"localhost"`
+		Port    int           `param:"port" required:"true"`
+		Timeout time.Duration `param:"timeout" default:"5" unit:"s"`
+	}
+
+	type Config struct {
+		DB    DBConfig        `param:"db"`
+		Hosts types.StringSet `param:"hosts"`
+	}
+
+param gives the option name, for nested structs it's used as a prefix so the Port field above is read as db.port,
+fields without the param tag are skipped except structs which are read without a prefix, time.Time is read with
+DefaultTimeLayouts and other structs implementing encoding.TextUnmarshaler are read from the option string,
+a tagged struct without tagged fields gives an unsupported type error,
+default is used if the option is not found, required:"true" gives an error for a missing option without default,
+defaults of slices and types.StringSet are comma separated lists e.g. default:"a,b",
+unit is one of ns, us, ms, s, m, h and is used to convert int values to time.Duration, seconds are used if not set.
+Values are converted with the same rules as in the Read* functions, errors for all missing or invalid fields
+are collected and returned as one error
+*/
+func (p *ParameterBag) Bind(target interface{}) error {
+	targetVal := reflect.ValueOf(target)
+	if targetVal.Kind() != reflect.Ptr || targetVal.IsNil() || targetVal.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind target should be a non nil pointer to a struct, %T given", target)
+	}
+
+	errs := errs2.NewErrorContainer()
+	p.bindStruct(targetVal.Elem(), "", errs)
+
+	return errs.Result(" ")
+}
+
+func (p *ParameterBag) bindStruct(structVal reflect.Value, prefix string, errs *errs2.ErrorContainer) {
+	structType := structVal.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := field.Tag.Get(paramTag)
+		if name == "-" {
+			continue
+		}
+
+		key := name
+		if prefix != "" && name != "" {
+			key = prefix + KeySeparator + name
+		}
+
+		fieldVal := structVal.Field(i)
+		if isNestedStruct(field.Type) && (name == "" || hasParamFields(field.Type)) {
+			if name == "" {
+				key = prefix
+			}
+			p.bindStruct(fieldVal, key, errs)
+			continue
+		}
+
+		if name == "" {
+			continue
+		}
+
+		errs.AddError(p.bindField(key, field, fieldVal))
+	}
+}
+
+// isNestedStruct tells if fields of a struct should be bound separately, structs which are read as one value
+// like time.Time or types.Decimal are not nested
+func isNestedStruct(fieldType reflect.Type) bool {
+	return fieldType.Kind() == reflect.Struct &&
+		fieldType != decimalType &&
+		fieldType != secretType &&
+		fieldType != timeType &&
+		!reflect.PtrTo(fieldType).Implements(textUnmarshalerType)
+}
+
+// hasParamFields tells if a struct has fields to bind, untagged nested structs are checked recursively
+func hasParamFields(structType reflect.Type) bool {
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := field.Tag.Get(paramTag)
+		if name != "" && name != "-" {
+			return true
+		}
+
+		if name == "" && isNestedStruct(field.Type) && hasParamFields(field.Type) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *ParameterBag) bindField(key string, field reflect.StructField, fieldVal reflect.Value) error {
+	sourceBag := p.untracked()
+	val, found, err := p.readValue(key)
+	defaultVal, hasDefault := field.Tag.Lookup(defaultTag)
+	if hasDefault {
+		p.recordUsage(key, field.Type.String(), defaultVal, false, found && err == nil)
+	} else {
+		p.recordUsage(key, field.Type.String(), nil, field.Tag.Get(requiredTag) == "true", found && err == nil)
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid option %s: %v", key, err)
+	}
+
+	if !found || val == nil {
+		if !hasDefault {
+			if field.Tag.Get(requiredTag) == "true" {
+				return fmt.Errorf("required option %s is empty", key)
+			}
+			return nil
+		}
+
+		sourceBag = New(NewMapValuesProvider(map[string]interface{}{key: defaultValue(field.Type, defaultVal)}))
+	}
+
+	unit := time.Second
+	if unitName, hasUnit := field.Tag.Lookup(unitTag); hasUnit {
+		var ok bool
+		unit, ok = durationUnits[unitName]
+		if !ok {
+			return fmt.Errorf("invalid unit %q of option %s", unitName, key)
+		}
+	}
+
+	err = sourceBag.setValue(key, unit, fieldVal)
+	if err != nil {
+		return fmt.Errorf("invalid option %s: %v", key, err)
+	}
+
+	return nil
+}
+
+// defaultValue splits defaults of list fields by commas as list values from env variables
+func defaultValue(fieldType reflect.Type, defaultVal string) interface{} {
+	if fieldType.Kind() != reflect.Slice || reflect.PtrTo(fieldType).Implements(textUnmarshalerType) {
+		return defaultVal
+	}
+
+	return splitList(defaultVal)
+}
+
+func (p *ParameterBag) setValue(key string, unit time.Duration, fieldVal reflect.Value) error {
+	isSet, err := p.setTypedValue(key, unit, fieldVal)
+	if isSet || err != nil {
+		return err
+	}
+
+	switch fieldVal.Kind() {
+	case reflect.String:
+		fieldVal.SetString(p.ReadString(key, ""))
+	case reflect.Bool:
+		boolVal, err := p.ReadRequiredBool(key)
+		if err != nil {
+			return err
+		}
+		fieldVal.SetBool(boolVal)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		intVal, err := p.ReadRequiredInt64(key)
+		if err != nil {
+			return err
+		}
+		if fieldVal.OverflowInt(intVal) {
+			return fmt.Errorf("value %d overflows %s", intVal, fieldVal.Type())
+		}
+		fieldVal.SetInt(intVal)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		uintVal, err := p.ReadRequiredUint(key)
+		if err != nil {
+			return err
+		}
+		if fieldVal.OverflowUint(uint64(uintVal)) {
+			return fmt.Errorf("value %d overflows %s", uintVal, fieldVal.Type())
+		}
+		fieldVal.SetUint(uint64(uintVal))
+	case reflect.Float32, reflect.Float64:
+		floatVal, err := p.ReadRequiredFloat64(key)
+		if err != nil {
+			return err
+		}
+		if fieldVal.OverflowFloat(floatVal) {
+			return fmt.Errorf("value %v overflows %s", floatVal, fieldVal.Type())
+		}
+		fieldVal.SetFloat(floatVal)
+	case reflect.Slice:
+		return p.setSliceValue(key, unit, fieldVal)
+	default:
+		return fmt.Errorf("unsupported type %s", fieldVal.Type())
+	}
+
+	return nil
+}
+
+// setTypedValue sets values for the types which are handled by type rather than by kind
+func (p *ParameterBag) setTypedValue(key string, unit time.Duration, fieldVal reflect.Value) (bool, error) {
+	switch fieldVal.Type() {
+	case durationType:
+		dur, err := p.ReadRequiredDuration(key, unit)
+		if err != nil {
+			return false, err
+		}
+		fieldVal.SetInt(int64(dur))
+	case decimalType:
+		dec, err := p.ReadRequiredDecimal(key)
+		if err != nil {
+			return false, err
+		}
+		fieldVal.Set(reflect.ValueOf(dec))
+	case stringSetType:
+		strs, err := p.ReadRequiredStrings(key)
+		if err != nil {
+			return false, err
+		}
+		fieldVal.Set(reflect.ValueOf(types.StringSet(strs)))
+	case secretType:
+		valI, err := p.ReadRequired(key)
+		if err != nil {
+			return false, err
+		}
+		secret, err := convertToSecret(valI)
+		if err != nil {
+			return false, err
+		}
+		fieldVal.Set(reflect.ValueOf(secret))
+	case timeType:
+		timeVal, err := p.ReadRequiredTime(key)
+		if err != nil {
+			return false, err
+		}
+		fieldVal.Set(reflect.ValueOf(timeVal))
+	default:
+		return p.setTextValue(key, fieldVal)
+	}
+
+	return true, nil
+}
+
+// setTextValue sets values of types implementing encoding.TextUnmarshaler e.g. net.IP
+func (p *ParameterBag) setTextValue(key string, fieldVal reflect.Value) (bool, error) {
+	if !fieldVal.CanAddr() || !fieldVal.Addr().Type().Implements(textUnmarshalerType) {
+		return false, nil
+	}
+
+	valI, err := p.ReadRequired(key)
+	if err != nil {
+		return false, err
+	}
+
+	err = fieldVal.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(fmt.Sprint(valI)))
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (p *ParameterBag) setSliceValue(key string, unit time.Duration, fieldVal reflect.Value) error {
+	items, err := p.ReadRequiredStrings(key)
+	if err != nil {
+		return err
+	}
+
+	sliceVal := reflect.MakeSlice(fieldVal.Type(), len(items), len(items))
+	for i, item := range items {
+		itemBag := New(NewMapValuesProvider(map[string]interface{}{key: item}))
+		err = itemBag.setValue(key, unit, sliceVal.Index(i))
+		if err != nil {
+			return err
+		}
+	}
+
+	fieldVal.Set(sliceVal)
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package options
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/breathbath/go_utils/v3/pkg/types"
+)
+
+type dbConfigMock struct {
+	Host    string        `param:"host" default:"localhost"`
+	Port    uint16        `param:"port" required:"true"`
+	Timeout time.Duration `param:"timeout" default:"5" unit:"s"`
+	Ping    time.Duration `param:"ping" unit:"ms"`
+}
+
+type limitsConfigMock struct {
+	MaxItems int `param:"max_items"`
+}
+
+type configMock struct {
+	DB       dbConfigMock `param:"db"`
+	Limits   limitsConfigMock
+	Name     string          `param:"name" required:"true"`
+	Debug    bool            `param:"debug"`
+	Ratio    float64         `param:"ratio" default:"0.5"`
+	Price    types.Decimal   `param:"price"`
+	Hosts    types.StringSet `param:"hosts"`
+	Password types.Secret    `param:"password"`
+	Ports    []int           `param:"ports"`
+	Tags     []string        `param:"tags" default:"one"`
+	Ignored  string          `param:"-"`
+	Untagged string
+	internal string
+}
+
+func TestBind(t *testing.T) {
+	jvp, err := NewJSONValuesProvider(strings.NewReader(`{
+		"db":{"port":5432,"ping":300},
+		"max_items":"20",
+		"name":"app",
+		"debug":"true",
+		"price":"12.34",
+		"hosts":["a.com","b.com"],
+		"password":"pass123",
+		"ports":[80,"8080"],
+		"Ignored":"some",
+		"Untagged":"some",
+		"internal":"some"
+	}`))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	cfg := configMock{}
+	err = New(jvp).Bind(&cfg)
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	assert.Equal(t, "localhost", cfg.DB.Host)
+	assert.Equal(t, uint16(5432), cfg.DB.Port)
+	assert.Equal(t, time.Second*5, cfg.DB.Timeout)
+	assert.Equal(t, time.Millisecond*300, cfg.DB.Ping)
+	assert.Equal(t, 20, cfg.Limits.MaxItems)
+	assert.Equal(t, "app", cfg.Name)
+	assert.True(t, cfg.Debug)
+	assert.Equal(t, 0.5, cfg.Ratio)
+	assert.Equal(t, "12.34", cfg.Price.String())
+	assert.Equal(t, types.StringSet{"a.com", "b.com"}, cfg.Hosts)
+	assert.Equal(t, "pass123", cfg.Password.Reveal())
+	assert.Equal(t, []int{80, 8080}, cfg.Ports)
+	assert.Equal(t, []string{"one"}, cfg.Tags)
+	assert.Equal(t, "", cfg.Ignored)
+	assert.Equal(t, "", cfg.Untagged)
+	assert.Equal(t, "", cfg.internal)
+}
+
+func TestBindAggregatedErrors(t *testing.T) {
+	mvp := NewMapValuesProvider(map[string]interface{}{
+		"db.port": 70000,
+		"ratio":   "high",
+		"ports":   []string{"80", "http"},
+		"price":   "cheap",
+		"debug":   nil,
+	})
+
+	cfg := configMock{}
+	err := New(mvp).Bind(&cfg)
+	assert.EqualError(
+		t,
+		err,
+		"invalid option db.port: value 70000 overflows uint16 "+
+			"required option name is empty "+
+			"invalid option ratio: cannot convert high to float "+
+			"invalid option price: cannot convert cheap to decimal "+
+			"invalid option ports: cannot convert http to int64",
+	)
+}
+
+type foreignStructMock struct {
+	Region string
+}
+
+func TestBindValueStructs(t *testing.T) {
+	mvp := NewMapValuesProvider(map[string]interface{}{
+		"started_at": "2021-02-03T04:05:06Z",
+		"window":     map[string]interface{}{"from": "2021-02-03", "ip": "10.0.0.1"},
+		"ips":        []string{"127.0.0.1", "::1"},
+	})
+
+	cfg := struct {
+		StartedAt time.Time `param:"started_at" required:"true"`
+		Window    struct {
+			From time.Time `param:"from"`
+			IP   net.IP    `param:"ip"`
+		} `param:"window"`
+		IPs       []net.IP  `param:"ips"`
+		StoppedAt time.Time `param:"stopped_at" default:"2022-01-01"`
+	}{}
+	err := New(mvp).Bind(&cfg)
+	assert.NoError(t, err)
+
+	assert.Equal(t, time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC), cfg.StartedAt)
+	assert.Equal(t, time.Date(2021, 2, 3, 0, 0, 0, 0, time.UTC), cfg.Window.From)
+	assert.Equal(t, "10.0.0.1", cfg.Window.IP.String())
+	assert.Equal(t, []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")}, cfg.IPs)
+	assert.Equal(t, time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), cfg.StoppedAt)
+
+	invalidCfg := struct {
+		StartedAt time.Time         `param:"started_at"`
+		IP        net.IP            `param:"ip"`
+		Foreign   foreignStructMock `param:"foreign"`
+	}{}
+	err = New(NewMapValuesProvider(map[string]interface{}{
+		"started_at": "yesterday",
+		"ip":         "localhost",
+		"foreign":    "eu",
+	})).Bind(&invalidCfg)
+	assert.Error(t, err)
+	if err != nil {
+		assert.Contains(t, err.Error(), "invalid option started_at: cannot convert yesterday to time")
+		assert.Contains(t, err.Error(), "invalid option ip: invalid IP address: localhost")
+		assert.Contains(t, err.Error(), "invalid option foreign: unsupported type options.foreignStructMock")
+	}
+}
+
+func TestBindListDefaults(t *testing.T) {
+	cfg := struct {
+		Hosts   []string        `param:"hosts" default:"a.com, b.com"`
+		Ports   []int           `param:"ports" default:"1,2"`
+		Tags    types.StringSet `param:"tags" default:"x,y"`
+		Empty   []string        `param:"empty" default:""`
+		IP      net.IP          `param:"ip" default:"127.0.0.1"`
+		Timeout []time.Duration `param:"timeouts" default:"1,500" unit:"ms"`
+	}{}
+	err := New(NewMapValuesProvider(map[string]interface{}{})).Bind(&cfg)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"a.com", "b.com"}, cfg.Hosts)
+	assert.Equal(t, []int{1, 2}, cfg.Ports)
+	assert.Equal(t, types.StringSet{"x", "y"}, cfg.Tags)
+	assert.Equal(t, []string{}, cfg.Empty)
+	assert.Equal(t, "127.0.0.1", cfg.IP.String())
+	assert.Equal(t, []time.Duration{time.Millisecond, 500 * time.Millisecond}, cfg.Timeout)
+}
+
+func TestBindInvalidTarget(t *testing.T) {
+	pb := New(nil)
+
+	err := pb.Bind(configMock{})
+	assert.EqualError(t, err, "bind target should be a non nil pointer to a struct, options.configMock given")
+
+	var cfg *configMock
+	err = pb.Bind(cfg)
+	assert.EqualError(t, err, "bind target should be a non nil pointer to a struct, *options.configMock given")
+
+	err = pb.Bind(&struct {
+		Timeout time.Duration     `param:"timeout" default:"1" unit:"days"`
+		Data    map[string]string `param:"data" default:"a"`
+	}{})
+	assert.EqualError(t, err, `invalid unit "days" of option timeout invalid option data: unsupported type map[string]string`)
+}
@@ -0,0 +1,139 @@
+package options
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+)
+
+// ChangeType tells how an option was changed between two configs
+type ChangeType string
+
+const (
+	ChangeAdded   ChangeType = "added"
+	ChangeRemoved ChangeType = "removed"
+	ChangeChanged ChangeType = "changed"
+)
+
+// Change describes a changed option, OldValue is empty for added options and NewValue is empty for removed ones
+type Change struct {
+	Key      string      `json:"key"`
+	Type     ChangeType  `json:"type"`
+	OldValue interface{} `json:"old_value,omitempty"`
+	NewValue interface{} `json:"new_value,omitempty"`
+}
+
+// Diff is a list of changed options sorted by their names
+type Diff struct {
+	Changes []Change
+}
+
+// DiffKeyValues compares flattened old and new values, so a changed nested option is reported by its dotted name
+func DiffKeyValues(oldKvs, newKvs map[string]interface{}) *Diff {
+	oldKvs = FlattenKeyValues(oldKvs)
+	newKvs = FlattenKeyValues(newKvs)
+
+	diff := &Diff{
+		Changes: []Change{},
+	}
+	for key, oldVal := range oldKvs {
+		newVal, found := newKvs[key]
+		switch {
+		case !found:
+			diff.Changes = append(diff.Changes, Change{Key: key, Type: ChangeRemoved, OldValue: oldVal})
+		case !reflect.DeepEqual(oldVal, newVal):
+			diff.Changes = append(diff.Changes, Change{Key: key, Type: ChangeChanged, OldValue: oldVal, NewValue: newVal})
+		}
+	}
+
+	for key, newVal := range newKvs {
+		if _, found := oldKvs[key]; !found {
+			diff.Changes = append(diff.Changes, Change{Key: key, Type: ChangeAdded, NewValue: newVal})
+		}
+	}
+
+	sort.Slice(diff.Changes, func(i, j int) bool {
+		return diff.Changes[i].Key < diff.Changes[j].Key
+	})
+
+	return diff
+}
+
+// DiffProviders compares values of two providers e.g. configs of the previous and the new release
+func DiffProviders(oldVP, newVP ValuesProvider) *Diff {
+	return DiffKeyValues(oldVP.ToKeyValues(), newVP.ToKeyValues())
+}
+
+// Diff compares values of the bag with values of newBag
+func (p *ParameterBag) Diff(newBag *ParameterBag) *Diff {
+	return DiffProviders(p.BaseValuesProvider, newBag.BaseValuesProvider)
+}
+
+// IsEmpty tells if there are no changes
+func (d *Diff) IsEmpty() bool {
+	return len(d.Changes) == 0
+}
+
+// Keys gives sorted names of all changed options
+func (d *Diff) Keys() []string {
+	keys := make([]string, 0, len(d.Changes))
+	for i := range d.Changes {
+		keys = append(keys, d.Changes[i].Key)
+	}
+
+	return keys
+}
+
+// Redacted gives a copy of the diff with sensitive values masked by CurrentRedactor
+func (d *Diff) Redacted() *Diff {
+	redactor := CurrentRedactor()
+	res := &Diff{
+		Changes: make([]Change, 0, len(d.Changes)),
+	}
+	for _, change := range d.Changes {
+		if change.OldValue != nil {
+			change.OldValue = redactor.RedactValue(change.Key, change.OldValue)
+		}
+		if change.NewValue != nil {
+			change.NewValue = redactor.RedactValue(change.Key, change.NewValue)
+		}
+		res.Changes = append(res.Changes, change)
+	}
+
+	return res
+}
+
+// WriteText outputs one line per change with masked sensitive values, added options are marked with +, removed ones
+// with - and changed ones with ~ e.g. ~ db.host = "localhost" -> "remote"
+func (d *Diff) WriteText(w io.Writer) error {
+	for _, change := range d.Changes {
+		var err error
+		switch change.Type {
+		case ChangeAdded:
+			_, err = fmt.Fprintf(w, "+ %s = %s\n", change.Key, formatSourceValue(change.Key, change.NewValue))
+		case ChangeRemoved:
+			_, err = fmt.Fprintf(w, "- %s = %s\n", change.Key, formatSourceValue(change.Key, change.OldValue))
+		default:
+			_, err = fmt.Fprintf(
+				w,
+				"~ %s = %s -> %s\n",
+				change.Key,
+				formatSourceValue(change.Key, change.OldValue),
+				formatSourceValue(change.Key, change.NewValue),
+			)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteJSON outputs the changes as json list with masked sensitive values
+func (d *Diff) WriteJSON(w io.Writer) error {
+	jsonEncoder := json.NewEncoder(w)
+	return jsonEncoder.Encode(d.Redacted().Changes)
+}
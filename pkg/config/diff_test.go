@@ -0,0 +1,127 @@
+package options
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffKeyValues(t *testing.T) {
+	diff := DiffKeyValues(
+		map[string]interface{}{
+			"db":          map[string]interface{}{"host": "localhost", "user": "app", "password": "old"},
+			"debug":       false,
+			"workers":     2,
+			"unchanged":   "same",
+			"tags":        []interface{}{"a"},
+			"db.timeout":  nil,
+			"api_version": "v1",
+		},
+		map[string]interface{}{
+			"db":          map[string]interface{}{"host": "remote", "password": "new", "port": 5432},
+			"debug":       true,
+			"workers":     2,
+			"unchanged":   "same",
+			"tags":        []interface{}{"a", "b"},
+			"api_version": "v1",
+		},
+	)
+
+	assert.Equal(
+		t,
+		[]Change{
+			{Key: "db.host", Type: ChangeChanged, OldValue: "localhost", NewValue: "remote"},
+			{Key: "db.password", Type: ChangeChanged, OldValue: "old", NewValue: "new"},
+			{Key: "db.port", Type: ChangeAdded, NewValue: 5432},
+			{Key: "db.timeout", Type: ChangeRemoved},
+			{Key: "db.user", Type: ChangeRemoved, OldValue: "app"},
+			{Key: "debug", Type: ChangeChanged, OldValue: false, NewValue: true},
+			{Key: "tags", Type: ChangeChanged, OldValue: []interface{}{"a"}, NewValue: []interface{}{"a", "b"}},
+		},
+		diff.Changes,
+	)
+	assert.Equal(t, []string{"db.host", "db.password", "db.port", "db.timeout", "db.user", "debug", "tags"}, diff.Keys())
+	assert.False(t, diff.IsEmpty())
+
+	b := &bytes.Buffer{}
+	err := diff.WriteText(b)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		`~ db.host = "localhost" -> "remote"
+~ db.password = "******" -> "******"
++ db.port = 5432
+- db.timeout = null
+- db.user = "app"
+~ debug = false -> true
+~ tags = ["a"] -> ["a","b"]
+`,
+		b.String(),
+	)
+
+	b.Reset()
+	err = diff.WriteJSON(b)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		`[{"key":"db.host","type":"changed","old_value":"localhost","new_value":"remote"},`+
+			`{"key":"db.password","type":"changed","old_value":"******","new_value":"******"},`+
+			`{"key":"db.port","type":"added","new_value":5432},`+
+			`{"key":"db.timeout","type":"removed"},`+
+			`{"key":"db.user","type":"removed","old_value":"app"},`+
+			`{"key":"debug","type":"changed","old_value":false,"new_value":true},`+
+			`{"key":"tags","type":"changed","old_value":["a"],"new_value":["a","b"]}]`+"\n",
+		b.String(),
+	)
+
+	assert.Equal(t, "old", diff.Changes[1].OldValue)
+	assert.True(t, DiffKeyValues(map[string]interface{}{"a": 1}, map[string]interface{}{"a": 1}).IsEmpty())
+}
+
+func TestParameterBagDiff(t *testing.T) {
+	oldProvider, err := NewJSONValuesProvider(strings.NewReader(`{"db":{"host":"localhost"},"api_token":"t1"}`))
+	require.NoError(t, err)
+	newProvider, err := NewYAMLValuesProvider(strings.NewReader("db:\n  host: remote\napi_token: t2\n"))
+	require.NoError(t, err)
+
+	diff := New(oldProvider).Diff(New(newProvider))
+	assert.Equal(t, []string{"api_token", "db.host"}, diff.Keys())
+
+	SetRedactor(NewUnmaskedRedactor())
+	defer SetRedactor(NewRedactor(DefaultSensitiveKeyPatterns, nil, nil))
+
+	b := &bytes.Buffer{}
+	err = diff.WriteText(b)
+	assert.NoError(t, err)
+	assert.Equal(t, "~ api_token = \"t1\" -> \"t2\"\n~ db.host = \"localhost\" -> \"remote\"\n", b.String())
+}
+
+func TestReloadableFileValuesProviderOnDiff(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "config.json")
+	startTime := time.Now().Add(-time.Hour)
+	writeConfigFile(t, filePath, `{"color":"red","password":"old"}`, startTime)
+
+	rfvp, err := NewReloadableFileValuesProvider(filePath, nil)
+	require.NoError(t, err)
+
+	diffs := []*Diff{}
+	rfvp.OnDiff(func(diff *Diff) {
+		diffs = append(diffs, diff)
+	})
+
+	writeConfigFile(t, filePath, `{"color":"blue","password":"new"}`, startTime.Add(time.Minute))
+	changedKeys, err := rfvp.Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"color", "password"}, changedKeys)
+	require.Len(t, diffs, 1)
+
+	b := &bytes.Buffer{}
+	err = diffs[0].WriteText(b)
+	assert.NoError(t, err)
+	assert.Equal(t, "~ color = \"red\" -> \"blue\"\n~ password = \"******\" -> \"******\"\n", b.String())
+}
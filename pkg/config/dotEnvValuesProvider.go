@@ -0,0 +1,221 @@
+package options
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/breathbath/go_utils/v3/pkg/conv"
+)
+
+var dotEnvKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// DotEnvValuesProvider gives values from a dotenv (.env) file without exporting them to the process environment
+type DotEnvValuesProvider struct {
+	vals MapValuesProvider
+}
+
+/*
+NewDotEnvValuesProvider parses dotenv formatted data e.g.
+
+	# comment
+	export DB_HOST=localhost
+	DB_PORT=5432 # inline comment
+	DB_USER='admin'
+	DB_DSN="postgres://${DB_USER}@${DB_HOST}:${DB_PORT:-5432}/app"
+	CERT="-----BEGIN CERTIFICATE-----
+	MIIB...
+	-----END CERTIFICATE-----"
+
+single quoted values are taken literally, double quoted values support \n, \t, \", \\ and \$ escapes,
+unquoted and double quoted values expand ${VAR} and ${VAR:-default} references, variables are looked up
+in the previously parsed lines and then in the process environment
+*/
+func NewDotEnvValuesProvider(dotEnvd io.Reader) (devp *DotEnvValuesProvider, err error) {
+	var data []byte
+	data, err = io.ReadAll(dotEnvd)
+	if err != nil {
+		return
+	}
+
+	params, err := parseDotEnv(string(data))
+	if err != nil {
+		return
+	}
+
+	return &DotEnvValuesProvider{
+		vals: MapValuesProvider{
+			parameters: conv.ConvertMapToSyncMap(params),
+		},
+	}, nil
+}
+
+func parseDotEnv(input string) (map[string]interface{}, error) {
+	params := map[string]interface{}{}
+	lookup := func(name string) (string, bool, error) {
+		if val, found := params[name]; found {
+			return fmt.Sprint(val), true, nil
+		}
+
+		val, found := os.LookupEnv(name)
+		return val, found, nil
+	}
+
+	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNumber := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "export ") || strings.HasPrefix(line, "export\t") {
+			line = strings.TrimSpace(line[len("export"):])
+		}
+
+		eqPos := strings.Index(line, "=")
+		if eqPos < 0 {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE, got %q", lineNumber, line)
+		}
+
+		key := strings.TrimSpace(line[:eqPos])
+		if !dotEnvKeyRegex.MatchString(key) {
+			return nil, fmt.Errorf("line %d: invalid key %q", lineNumber, key)
+		}
+
+		rawVal := strings.TrimLeft(line[eqPos+1:], " \t")
+		if rawVal == "" || (rawVal[0] != '"' && rawVal[0] != '\'') {
+			val, err := ExpandVariables(stripInlineComment(rawVal), lookup)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+			}
+			params[key] = val
+			continue
+		}
+
+		quote := rawVal[0]
+		content := rawVal[1:]
+		endPos := findClosingQuote(content, quote)
+		for endPos < 0 {
+			i++
+			if i >= len(lines) {
+				return nil, fmt.Errorf("line %d: unterminated quoted value of %s", lineNumber, key)
+			}
+			content += "\n" + lines[i]
+			endPos = findClosingQuote(content, quote)
+		}
+
+		trailing := strings.TrimSpace(content[endPos+1:])
+		if trailing != "" && !strings.HasPrefix(trailing, "#") {
+			return nil, fmt.Errorf("line %d: unexpected characters %q after the quoted value of %s", lineNumber, trailing, key)
+		}
+
+		val := content[:endPos]
+		if quote == '"' {
+			var err error
+			val, err = ExpandVariables(unescapeDoubleQuoted(val), lookup)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+			}
+		}
+		params[key] = val
+	}
+
+	return params, nil
+}
+
+// stripInlineComment removes comments which are separated from an unquoted value by whitespace
+func stripInlineComment(val string) string {
+	for i := 1; i < len(val); i++ {
+		if val[i] == '#' && (val[i-1] == ' ' || val[i-1] == '\t') {
+			val = val[:i]
+			break
+		}
+	}
+
+	if strings.HasPrefix(val, "#") {
+		return ""
+	}
+
+	return strings.TrimSpace(val)
+}
+
+func findClosingQuote(content string, quote byte) int {
+	for i := 0; i < len(content); i++ {
+		if quote == '"' && content[i] == '\\' {
+			i++
+			continue
+		}
+
+		if content[i] == quote {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func unescapeDoubleQuoted(val string) string {
+	res := strings.Builder{}
+	for i := 0; i < len(val); i++ {
+		if val[i] != '\\' || i == len(val)-1 {
+			res.WriteByte(val[i])
+			continue
+		}
+
+		i++
+		switch val[i] {
+		case 'n':
+			res.WriteByte('\n')
+		case 't':
+			res.WriteByte('\t')
+		case 'r':
+			res.WriteByte('\r')
+		case '$':
+			// ExpandVariables keeps $${ as literal ${
+			if i+1 < len(val) && val[i+1] == '{' {
+				res.WriteString("$$")
+			} else {
+				res.WriteByte('$')
+			}
+		case '"', '\\':
+			res.WriteByte(val[i])
+		default:
+			res.WriteByte('\\')
+			res.WriteByte(val[i])
+		}
+	}
+
+	return res.String()
+}
+
+func (devp *DotEnvValuesProvider) Read(name string) (val interface{}, found bool) {
+	return devp.vals.Read(name)
+}
+
+func (devp *DotEnvValuesProvider) Dump(w io.Writer) (err error) {
+	return devp.vals.Dump(w)
+}
+
+// DumpFormatted outputs values in the given format, see DumpFormatted
+func (devp *DotEnvValuesProvider) DumpFormatted(w io.Writer, format DumpFormat) error {
+	return DumpFormatted(devp, w, format)
+}
+
+func (devp *DotEnvValuesProvider) Label() string {
+	return "dotenv"
+}
+
+func (devp *DotEnvValuesProvider) KeyStyle() KeyStyle {
+	return KeyStyleEnv
+}
+
+func (devp *DotEnvValuesProvider) HasStringValues() bool {
+	return true
+}
+
+func (devp *DotEnvValuesProvider) ToKeyValues() map[string]interface{} {
+	return devp.vals.ToKeyValues()
+}
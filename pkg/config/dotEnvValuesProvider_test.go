@@ -0,0 +1,144 @@
+package options
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const dotEnvInput = `
+# database settings
+export DB_HOST=localhost
+DB_PORT=5432 # default port
+DB_USER='admin ${NOT_EXPANDED}'
+DB_PASS="pa\"ss\$word"
+DB_DSN="postgres://${DB_HOST}:${DB_PORT}/${DB_NAME:-app}"
+FROM_ENV=${SOME_DOTENV_REAL_ENV}
+LITERAL="\${DB_HOST} $${DB_HOST}"
+EMPTY=
+MULTILINE="line1
+line2\nline3"
+SINGLE_MULTILINE='one
+two'
+HASH=a#b
+`
+
+func TestDotEnvValuesProviderRead(t *testing.T) {
+	err := os.Setenv("SOME_DOTENV_REAL_ENV", "realEnvVal")
+	assert.NoError(t, err)
+	defer func() {
+		err = os.Unsetenv("SOME_DOTENV_REAL_ENV")
+		if err != nil {
+			log.Println(err.Error())
+		}
+	}()
+
+	devp, err := NewDotEnvValuesProvider(strings.NewReader(dotEnvInput))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	expectedValues := map[string]interface{}{
+		"DB_HOST":          "localhost",
+		"DB_PORT":          "5432",
+		"DB_USER":          "admin ${NOT_EXPANDED}",
+		"DB_PASS":          `pa"ss$word`,
+		"DB_DSN":           "postgres://localhost:5432/app",
+		"FROM_ENV":         "realEnvVal",
+		"LITERAL":          "${DB_HOST} ${DB_HOST}",
+		"EMPTY":            "",
+		"MULTILINE":        "line1\nline2\nline3",
+		"SINGLE_MULTILINE": "one\ntwo",
+		"HASH":             "a#b",
+	}
+
+	for key, expectedVal := range expectedValues {
+		val, found := devp.Read(key)
+		assert.True(t, found, key)
+		assert.Equal(t, expectedVal, val, key)
+	}
+
+	assert.Equal(t, expectedValues, devp.ToKeyValues())
+
+	_, found := devp.Read("NOT_EXPANDED")
+	assert.False(t, found)
+
+	pb := New(devp)
+	assert.Equal(t, 5432, pb.ReadInt("DB_PORT", 0))
+}
+
+func TestDotEnvValuesProviderDump(t *testing.T) {
+	devp, err := NewDotEnvValuesProvider(strings.NewReader("COLOR=red\nSIZE=2"))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	b := &bytes.Buffer{}
+	err = devp.Dump(b)
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), `"COLOR":"red"`)
+	assert.Contains(t, b.String(), `"SIZE":"2"`)
+}
+
+func TestDotEnvValuesProviderInCompositeWithEnv(t *testing.T) {
+	err := os.Setenv("SOME_DOTENV_OVERRIDE", "fromEnv")
+	assert.NoError(t, err)
+	defer func() {
+		err = os.Unsetenv("SOME_DOTENV_OVERRIDE")
+		if err != nil {
+			log.Println(err.Error())
+		}
+	}()
+
+	devp, err := NewDotEnvValuesProvider(strings.NewReader("SOME_DOTENV_OVERRIDE=fromFile\nSOME_DOTENV_ONLY=fromFile"))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	pb := New(NewValuesProviderComposite(&EnvValuesProvider{}, devp))
+	assert.Equal(t, "fromEnv", pb.ReadString("SOME_DOTENV_OVERRIDE", ""))
+	assert.Equal(t, "fromFile", pb.ReadString("SOME_DOTENV_ONLY", ""))
+}
+
+func TestDotEnvValuesProviderInvalidInput(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedError string
+	}{
+		{
+			input:         "\nNO_VALUE",
+			expectedError: `line 2: expected KEY=VALUE, got "NO_VALUE"`,
+		},
+		{
+			input:         "1KEY=val",
+			expectedError: `line 1: invalid key "1KEY"`,
+		},
+		{
+			input:         "KEY=\"val\nother",
+			expectedError: "line 1: unterminated quoted value of KEY",
+		},
+		{
+			input:         "KEY='val' tail",
+			expectedError: `line 1: unexpected characters "tail" after the quoted value of KEY`,
+		},
+		{
+			input:         "KEY=${OTHER",
+			expectedError: `line 1: unterminated variable reference in "${OTHER"`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		_, err := NewDotEnvValuesProvider(strings.NewReader(testCase.input))
+		assert.EqualError(t, err, testCase.expectedError)
+	}
+
+	_, err := NewDotEnvValuesProvider(failingReader{})
+	assert.EqualError(t, err, "some error")
+}
@@ -0,0 +1,226 @@
+package options
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DumpFormat defines the output format of DumpFormatted
+type DumpFormat string
+
+const (
+	// DumpFormatJSON is a single line json as in Dump
+	DumpFormatJSON DumpFormat = "json"
+	// DumpFormatPrettyJSON is an indented json with sorted keys
+	DumpFormatPrettyJSON DumpFormat = "pretty_json"
+	// DumpFormatEnv gives sorted KEY=value lines which can be read by NewDotEnvValuesProvider, values with special
+	// characters are double quoted, use DumpFormatDockerEnv for docker --env-file which keeps quotes as they are
+	DumpFormatEnv DumpFormat = "env"
+	// DumpFormatDockerEnv gives sorted KEY=value lines for docker --env-file, values are written as they are
+	// without quotes, since docker doesn't unquote them, values with line breaks cannot be written and give an error
+	DumpFormatDockerEnv DumpFormat = "docker_env"
+	// DumpFormatYAML is a yaml document with sorted keys
+	DumpFormatYAML DumpFormat = "yaml"
+	// DumpFormatTable is an aligned table of sorted flat option names and values
+	DumpFormatTable DumpFormat = "table"
+)
+
+var dumpFormats = []DumpFormat{
+	DumpFormatJSON,
+	DumpFormatPrettyJSON,
+	DumpFormatEnv,
+	DumpFormatDockerEnv,
+	DumpFormatYAML,
+	DumpFormatTable,
+}
+
+// FormattedDumper is implemented by values providers which can output their values in all DumpFormat formats
+type FormattedDumper interface {
+	DumpFormatted(w io.Writer, format DumpFormat) error
+}
+
+// ParseDumpFormat converts a format name e.g. from a command line flag to DumpFormat
+func ParseDumpFormat(name string) (DumpFormat, error) {
+	formatNames := make([]string, 0, len(dumpFormats))
+	for _, format := range dumpFormats {
+		if strings.EqualFold(name, string(format)) {
+			return format, nil
+		}
+		formatNames = append(formatNames, string(format))
+	}
+
+	return "", fmt.Errorf("unknown dump format %q, expected one of %s", name, strings.Join(formatNames, ", "))
+}
+
+// EnvNameFromKey converts an option name to an env variable name e.g. db.host, db-host or dbHost to DB_HOST
+func EnvNameFromKey(key string) string {
+	name := []byte(strings.ToUpper(FlagNameFromKey(key)))
+	for i, b := range name {
+		if (b < 'A' || b > 'Z') && (b < '0' || b > '9') {
+			name[i] = '_'
+		}
+	}
+
+	if len(name) > 0 && name[0] >= '0' && name[0] <= '9' {
+		return "_" + string(name)
+	}
+
+	return string(name)
+}
+
+/*
+DumpFormatted outputs values of vp in the given format, sensitive values are masked by CurrentRedactor,
+nested values are given as flat dotted names in the env and table formats, in the env formats names are converted
+with EnvNameFromKey and lists of scalars are joined with commas, in DumpFormatEnv values are double quoted only
+if they contain characters which would be changed by a dotenv parser e.g. #, quotes, $ or line breaks,
+in DumpFormatDockerEnv values are never quoted
+*/
+func DumpFormatted(vp ValuesProvider, w io.Writer, format DumpFormat) error {
+	kvs := CurrentRedactor().Redact(vp.ToKeyValues())
+
+	switch format {
+	case DumpFormatJSON:
+		return json.NewEncoder(w).Encode(kvs)
+	case DumpFormatPrettyJSON:
+		jsonEncoder := json.NewEncoder(w)
+		jsonEncoder.SetIndent("", "  ")
+		return jsonEncoder.Encode(kvs)
+	case DumpFormatYAML:
+		yamlEncoder := yaml.NewEncoder(w)
+		yamlEncoder.SetIndent(2)
+		err := yamlEncoder.Encode(kvs)
+		if err != nil {
+			return err
+		}
+		return yamlEncoder.Close()
+	case DumpFormatEnv:
+		return dumpEnv(w, FlattenKeyValues(kvs), formatEnvValue)
+	case DumpFormatDockerEnv:
+		return dumpEnv(w, FlattenKeyValues(kvs), formatDockerEnvValue)
+	case DumpFormatTable:
+		return dumpTable(w, FlattenKeyValues(kvs))
+	default:
+		return fmt.Errorf("unknown dump format %q", format)
+	}
+}
+
+// DumpFormatted outputs values in the given format, sensitive values are masked by CurrentRedactor
+func (p *ParameterBag) DumpFormatted(w io.Writer, format DumpFormat) error {
+	if p.BaseValuesProvider == nil {
+		p.BaseValuesProvider = &NullValuesProvider{}
+	}
+
+	return DumpFormatted(p.BaseValuesProvider, w, format)
+}
+
+func sortedKeys(kvs map[string]interface{}) []string {
+	keys := make([]string, 0, len(kvs))
+	for key := range kvs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// dumpEnv fails if several options have the same env name e.g. db.host and db_host, so no value is silently dropped,
+// nothing is written if a value cannot be formatted
+func dumpEnv(w io.Writer, kvs map[string]interface{}, formatValue func(val interface{}) (string, error)) error {
+	envKvs := make(map[string]interface{}, len(kvs))
+	envKeys := make(map[string]string, len(kvs))
+	for _, key := range sortedKeys(kvs) {
+		envKey := EnvNameFromKey(key)
+		if prevKey, exists := envKeys[envKey]; exists {
+			return fmt.Errorf("options %s and %s have the same env name %s", prevKey, key, envKey)
+		}
+		envKeys[envKey] = key
+		envKvs[envKey] = kvs[key]
+	}
+
+	lines := make([]string, 0, len(envKvs))
+	for _, key := range sortedKeys(envKvs) {
+		valStr, err := formatValue(envKvs[key])
+		if err != nil {
+			return fmt.Errorf("cannot dump option %s as %s: %v", envKeys[key], key, err)
+		}
+		lines = append(lines, key+"="+valStr+"\n")
+	}
+
+	_, err := io.WriteString(w, strings.Join(lines, ""))
+
+	return err
+}
+
+func envValueString(val interface{}) string {
+	if strs, ok := convertToStrings(val); ok {
+		return strings.Join(strs, ",")
+	}
+
+	if val == nil {
+		return ""
+	}
+
+	return fmt.Sprint(val)
+}
+
+func formatEnvValue(val interface{}) (string, error) {
+	valStr := envValueString(val)
+	if !strings.ContainsAny(valStr, "#\"'\\$\n\r") && strings.TrimSpace(valStr) == valStr {
+		return valStr, nil
+	}
+
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+	return `"` + escaper.Replace(valStr) + `"`, nil
+}
+
+// formatDockerEnvValue gives the raw value, docker --env-file reads everything after = as the value
+func formatDockerEnvValue(val interface{}) (string, error) {
+	valStr := envValueString(val)
+	if strings.ContainsAny(valStr, "\n\r\x00") {
+		return "", errors.New("docker env files cannot contain line breaks or null characters in values")
+	}
+
+	return valStr, nil
+}
+
+func dumpTable(w io.Writer, kvs map[string]interface{}) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, err := fmt.Fprintln(tw, "OPTION\tVALUE")
+	if err != nil {
+		return err
+	}
+
+	for _, key := range sortedKeys(kvs) {
+		_, err = fmt.Fprintf(tw, "%s\t%s\n", key, formatTableValue(kvs[key]))
+		if err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
+
+func formatTableValue(val interface{}) string {
+	valStr, isString := val.(string)
+	if !isString {
+		data, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Sprint(val)
+		}
+		return string(data)
+	}
+
+	if strings.ContainsAny(valStr, "\t\n\r") {
+		return fmt.Sprintf("%q", valStr)
+	}
+
+	return valStr
+}
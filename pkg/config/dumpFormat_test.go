@@ -0,0 +1,256 @@
+package options
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildDumpProviderMock(t *testing.T) ValuesProvider {
+	jsonProvider, err := NewJSONValuesProvider(strings.NewReader(`{
+		"db": {"host": "localhost", "port": 5432, "password": "s3cr3t"},
+		"hosts": ["a.com", "b.com"],
+		"debug": true,
+		"ratio": 0.5,
+		"note": "say \"hi\" # not a comment",
+		"template": "${HOME} costs $5",
+		"multiline": "line1\nline2",
+		"padded": " value ",
+		"empty": "",
+		"nothing": null
+	}`))
+	require.NoError(t, err)
+
+	return NewValuesProviderComposite(
+		NewMapValuesProvider(map[string]interface{}{"appName": "app", "servers": []interface{}{map[string]interface{}{"port": 80}}}),
+		jsonProvider,
+	)
+}
+
+func TestDumpFormattedEnv(t *testing.T) {
+	b := &bytes.Buffer{}
+	err := New(buildDumpProviderMock(t)).DumpFormatted(b, DumpFormatEnv)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		`APP_NAME=app
+DB_HOST=localhost
+DB_PASSWORD=******
+DB_PORT=5432
+DEBUG=true
+EMPTY=
+HOSTS=a.com,b.com
+MULTILINE="line1\nline2"
+NOTE="say \"hi\" # not a comment"
+NOTHING=
+PADDED=" value "
+RATIO=0.5
+SERVERS_0_PORT=80
+TEMPLATE="\${HOME} costs \$5"
+`,
+		b.String(),
+	)
+
+	dotEnvProvider, err := NewDotEnvValuesProvider(strings.NewReader(b.String()))
+	require.NoError(t, err)
+	pb := New(dotEnvProvider)
+	assert.Equal(t, "line1\nline2", pb.ReadString("MULTILINE", ""))
+	assert.Equal(t, `say "hi" # not a comment`, pb.ReadString("NOTE", ""))
+	assert.Equal(t, "${HOME} costs $5", pb.ReadString("TEMPLATE", ""))
+	assert.Equal(t, " value ", pb.ReadString("PADDED", ""))
+	assert.Equal(t, "", pb.ReadString("EMPTY", "default"))
+	assert.Equal(t, 5432, pb.ReadInt("DB_PORT", 0))
+	assert.Equal(t, "a.com,b.com", pb.ReadString("HOSTS", ""))
+}
+
+func TestDumpFormattedDockerEnv(t *testing.T) {
+	b := &bytes.Buffer{}
+	err := New(buildDumpProviderMock(t)).DumpFormatted(b, DumpFormatDockerEnv)
+	assert.EqualError(
+		t,
+		err,
+		"cannot dump option multiline as MULTILINE: docker env files cannot contain line breaks or null characters in values",
+	)
+	assert.Equal(t, "", b.String())
+
+	mvp := NewMapValuesProvider(map[string]interface{}{
+		"note":     `say "hi" # not a comment`,
+		"template": "${HOME} costs $5",
+		"path":     `C:\app`,
+		"padded":   " value ",
+		"hosts":    []interface{}{"a.com", "b.com"},
+		"db":       map[string]interface{}{"password": "s3cr3t"},
+	})
+	b.Reset()
+	err = mvp.DumpFormatted(b, DumpFormatDockerEnv)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		`DB_PASSWORD=******
+HOSTS=a.com,b.com
+NOTE=say "hi" # not a comment
+PADDED= value 
+PATH=C:\app
+TEMPLATE=${HOME} costs $5
+`,
+		b.String(),
+	)
+}
+
+func TestProvidersDumpFormatted(t *testing.T) {
+	t.Setenv("DB_HOST", "localhost")
+
+	mvp := NewMapValuesProvider(map[string]interface{}{"db": map[string]interface{}{"host": "localhost"}})
+	encryptedProvider, err := NewEncryptedValuesProvider(mvp, make([]byte, 32))
+	require.NoError(t, err)
+
+	dumpers := map[string]FormattedDumper{
+		"map":          mvp,
+		"composite":    NewValuesProviderComposite(mvp),
+		"sub":          NewPrefixedValuesProvider(NewValuesProviderComposite(mvp), "", SubOptions{}),
+		"labeled":      NewLabeledValuesProvider("defaults", mvp),
+		"interpolated": NewInterpolatingValuesProvider(mvp),
+		"file_ref":     NewFileRefValuesProvider(mvp),
+		"encrypted":    encryptedProvider,
+		"env":          NewEnvOverridesValuesProvider(mvp),
+	}
+	for name, dumper := range dumpers {
+		b := &bytes.Buffer{}
+		err = dumper.DumpFormatted(b, DumpFormatTable)
+		assert.NoError(t, err, name)
+		assert.Contains(t, b.String(), "db.host  localhost", name)
+	}
+
+	_ = []FormattedDumper{
+		&NullValuesProvider{},
+		&EnvValuesProvider{},
+		&JSONFileValuesProvider{},
+		&YAMLValuesProvider{},
+		&DotEnvValuesProvider{},
+		&FlagValuesProvider{},
+		&HTTPValuesProvider{},
+		&ReloadableFileValuesProvider{},
+		&WritableJSONValuesProvider{},
+		&SecretsDirValuesProvider{},
+	}
+}
+
+func TestDumpFormattedEnvNameCollision(t *testing.T) {
+	pb := New(NewMapValuesProvider(map[string]interface{}{
+		"db":      map[string]interface{}{"host": "a"},
+		"db_host": "b",
+	}))
+
+	b := &bytes.Buffer{}
+	err := pb.DumpFormatted(b, DumpFormatEnv)
+	assert.EqualError(t, err, "options db.host and db_host have the same env name DB_HOST")
+	assert.Equal(t, "", b.String())
+}
+
+func TestDumpFormattedTable(t *testing.T) {
+	b := &bytes.Buffer{}
+	err := DumpFormatted(buildDumpProviderMock(t), b, DumpFormatTable)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		`OPTION          VALUE
+appName         app
+db.host         localhost
+db.password     ******
+db.port         5432
+debug           true
+empty           
+hosts           ["a.com","b.com"]
+multiline       "line1\nline2"
+note            say "hi" # not a comment
+nothing         null
+padded           value 
+ratio           0.5
+servers.0.port  80
+template        ${HOME} costs $5
+`,
+		b.String(),
+	)
+}
+
+func TestDumpFormattedJSONAndYAML(t *testing.T) {
+	mvp := NewMapValuesProvider(map[string]interface{}{
+		"db":    map[string]interface{}{"host": "localhost", "password": "s3cr3t"},
+		"hosts": []interface{}{"a.com"},
+		"debug": false,
+	})
+
+	b := &bytes.Buffer{}
+	err := DumpFormatted(mvp, b, DumpFormatPrettyJSON)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		`{
+  "db": {
+    "host": "localhost",
+    "password": "******"
+  },
+  "debug": false,
+  "hosts": [
+    "a.com"
+  ]
+}
+`,
+		b.String(),
+	)
+
+	b.Reset()
+	err = DumpFormatted(mvp, b, DumpFormatJSON)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"db":{"host":"localhost","password":"******"},"debug":false,"hosts":["a.com"]}`+"\n", b.String())
+
+	b.Reset()
+	err = DumpFormatted(mvp, b, DumpFormatYAML)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		`db:
+  host: localhost
+  password: '******'
+debug: false
+hosts:
+  - a.com
+`,
+		b.String(),
+	)
+
+	yamlProvider, err := NewYAMLValuesProvider(strings.NewReader(b.String()))
+	require.NoError(t, err)
+	assert.Equal(t, "localhost", New(yamlProvider).ReadString("db.host", ""))
+
+	err = DumpFormatted(mvp, b, "xml")
+	assert.EqualError(t, err, `unknown dump format "xml"`)
+}
+
+func TestParseDumpFormat(t *testing.T) {
+	format, err := ParseDumpFormat("YAML")
+	assert.NoError(t, err)
+	assert.Equal(t, DumpFormatYAML, format)
+
+	_, err = ParseDumpFormat("xml")
+	assert.EqualError(t, err, `unknown dump format "xml", expected one of json, pretty_json, env, docker_env, yaml, table`)
+}
+
+func TestEnvNameFromKey(t *testing.T) {
+	testCases := map[string]string{
+		"db.host":       "DB_HOST",
+		"dbHost":        "DB_HOST",
+		"db-max-conns":  "DB_MAX_CONNS",
+		"HTTPS_PROXY":   "HTTPS_PROXY",
+		"servers.0.url": "SERVERS_0_URL",
+		"0day":          "_0DAY",
+		"a b":           "A_B",
+	}
+
+	for key, expectedName := range testCases {
+		assert.Equal(t, expectedName, EnvNameFromKey(key), key)
+	}
+}
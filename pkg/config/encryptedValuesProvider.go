@@ -0,0 +1,293 @@
+package options
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/breathbath/go_utils/v3/pkg/enc"
+	io2 "github.com/breathbath/go_utils/v3/pkg/io"
+	"github.com/breathbath/go_utils/v3/pkg/types"
+)
+
+const (
+	// EncryptedValuePrefix marks values encrypted with EncryptValue e.g. enc:v1:c2VjcmV0...
+	EncryptedValuePrefix = "enc:v1:"
+	// DefaultEncryptionKeyEnv is the env variable with the base64 encoded key of encrypted values
+	DefaultEncryptionKeyEnv = "CONFIG_ENCRYPTION_KEY"
+)
+
+// decodeEncryptionKey decodes a base64 encoded AES key of 16, 24 or 32 bytes
+func decodeEncryptionKey(encodedKey string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encodedKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 encoding of the encryption key: %v", err)
+	}
+
+	err = checkEncryptionKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func checkEncryptionKey(key []byte) error {
+	switch len(key) {
+	case 16, 24, 32:
+		return nil
+	default:
+		return fmt.Errorf("invalid encryption key size %d, 16, 24 or 32 bytes are expected", len(key))
+	}
+}
+
+// EncryptionKeyFromEnv reads a base64 encoded encryption key from the env variable
+func EncryptionKeyFromEnv(envName string) ([]byte, error) {
+	encodedKey, found := os.LookupEnv(envName)
+	if !found || encodedKey == "" {
+		return nil, fmt.Errorf("encryption key is not set in %s env variable", envName)
+	}
+
+	return decodeEncryptionKey(encodedKey)
+}
+
+// EncryptionKeyFromFile reads a base64 encoded encryption key from the file
+func EncryptionKeyFromFile(filePath string) ([]byte, error) {
+	encodedKey, err := readValueFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the encryption key: %v", err)
+	}
+
+	return decodeEncryptionKey(encodedKey)
+}
+
+// ReadEncryptionKey reads the encryption key from the env variable or from the key file if the variable is not set
+func ReadEncryptionKey(envName, keyFilePath string) ([]byte, error) {
+	if keyFilePath == "" || os.Getenv(envName) != "" {
+		return EncryptionKeyFromEnv(envName)
+	}
+
+	return EncryptionKeyFromFile(keyFilePath)
+}
+
+// GenerateEncryptionKey gives a random base64 encoded key which can be stored in an env variable or a key file
+func GenerateEncryptionKey() (string, error) {
+	key, err := enc.GenerateAESKey()
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// EncryptValue encrypts val with AES-GCM to be stored in config files, the output has the EncryptedValuePrefix
+func EncryptValue(val string, key []byte) (string, error) {
+	encrypted, err := enc.EncryptAESGCM([]byte(val), key)
+	if err != nil {
+		return "", err
+	}
+
+	return EncryptedValuePrefix + base64.StdEncoding.EncodeToString(encrypted), nil
+}
+
+// DecryptValue decrypts the output of EncryptValue, values without EncryptedValuePrefix are given as they are
+func DecryptValue(val string, key []byte) (string, error) {
+	if !strings.HasPrefix(val, EncryptedValuePrefix) {
+		return val, nil
+	}
+
+	encrypted, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(val, EncryptedValuePrefix))
+	if err != nil {
+		return "", fmt.Errorf("invalid base64 encoding of the encrypted value: %v", err)
+	}
+
+	decrypted, err := enc.DecryptAESGCM(encrypted, key)
+	if err != nil {
+		return "", err
+	}
+
+	return string(decrypted), nil
+}
+
+/*
+EncryptedValuesProvider decrypts string values of the wrapped provider which start with EncryptedValuePrefix,
+values in nested objects and lists are decrypted as well, other values are given as they are,
+a value which cannot be decrypted is never given as ciphertext, Read doesn't find it and ReadWithError gives the error
+*/
+type EncryptedValuesProvider struct {
+	base ValuesProvider
+	key  []byte
+}
+
+// NewEncryptedValuesProvider wraps vp to decrypt its values with the key of 16, 24 or 32 bytes
+func NewEncryptedValuesProvider(vp ValuesProvider, key []byte) (*EncryptedValuesProvider, error) {
+	err := checkEncryptionKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if vp == nil {
+		vp = &NullValuesProvider{}
+	}
+
+	return &EncryptedValuesProvider{
+		base: vp,
+		key:  key,
+	}, nil
+}
+
+func (evp *EncryptedValuesProvider) decrypt(name string, val interface{}) (interface{}, error) {
+	decryptedVal, err := mapStringValues(val, func(input string) (string, error) {
+		return DecryptValue(input, evp.key)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt option %s: %v", name, err)
+	}
+
+	return decryptedVal, nil
+}
+
+func (evp *EncryptedValuesProvider) Read(name string) (val interface{}, found bool) {
+	val, found, _ = evp.ReadWithError(name)
+	return
+}
+
+// ReadWithError same as Read but gives an error if the value cannot be decrypted
+func (evp *EncryptedValuesProvider) ReadWithError(name string) (val interface{}, found bool, err error) {
+	val, found, err = ReadValue(evp.base, name)
+	if err != nil || !found {
+		return
+	}
+
+	val, err = evp.decrypt(name, val)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return val, true, nil
+}
+
+/*
+ToKeyValues gives values of the wrapped provider where decrypted values are wrapped in types.Secret, so they are masked
+in dumps and diffs of bags and composites over the provider even if their keys don't look sensitive, use Read
+or ReadWithError to get plain values, values which cannot be decrypted are skipped and the errors are logged
+with io.OutputError
+*/
+func (evp *EncryptedValuesProvider) ToKeyValues() map[string]interface{} {
+	baseKvs := evp.base.ToKeyValues()
+	res := make(map[string]interface{}, len(baseKvs))
+	for key, val := range baseKvs {
+		secretVal, err := evp.decryptToSecrets(key, val)
+		if err != nil {
+			io2.OutputError(err, ReadLoggingTopic, "encrypted provider %s skips option %s", ProviderLabel(evp.base), key)
+			continue
+		}
+		res[key] = secretVal
+	}
+
+	return res
+}
+
+// decryptToSecrets gives val where encrypted strings are replaced with decrypted types.Secret values
+func (evp *EncryptedValuesProvider) decryptToSecrets(name string, val interface{}) (interface{}, error) {
+	switch typedVal := val.(type) {
+	case string:
+		if !strings.HasPrefix(typedVal, EncryptedValuePrefix) {
+			return typedVal, nil
+		}
+		decrypted, err := DecryptValue(typedVal, evp.key)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decrypt option %s: %v", name, err)
+		}
+		return types.NewSecret(decrypted), nil
+	case []string:
+		items := make([]interface{}, len(typedVal))
+		for i, item := range typedVal {
+			items[i] = item
+		}
+		return evp.decryptToSecrets(name, items)
+	case []interface{}:
+		res := make([]interface{}, 0, len(typedVal))
+		for i, item := range typedVal {
+			secretItem, err := evp.decryptToSecrets(name+KeySeparator+strconv.Itoa(i), item)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, secretItem)
+		}
+		return res, nil
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(typedVal))
+		for key, item := range typedVal {
+			secretItem, err := evp.decryptToSecrets(name+KeySeparator+key, item)
+			if err != nil {
+				return nil, err
+			}
+			res[key] = secretItem
+		}
+		return res, nil
+	default:
+		return val, nil
+	}
+}
+
+// Dump outputs values as json, encrypted values are always masked and other sensitive values are masked
+// by CurrentRedactor
+func (evp *EncryptedValuesProvider) Dump(w io.Writer) (err error) {
+	baseKvs := evp.base.ToKeyValues()
+	kvs := make(map[string]interface{}, len(baseKvs))
+	for key, val := range baseKvs {
+		kvs[key], err = mapStringValues(val, maskEncryptedValue)
+		if err != nil {
+			return err
+		}
+	}
+
+	data := CurrentRedactor().Redact(kvs)
+	jsonEncoder := json.NewEncoder(w)
+	return jsonEncoder.Encode(data)
+}
+
+// DumpFormatted outputs values in the given format, see DumpFormatted
+func (evp *EncryptedValuesProvider) DumpFormatted(w io.Writer, format DumpFormat) error {
+	return DumpFormatted(evp, w, format)
+}
+
+func maskEncryptedValue(val string) (string, error) {
+	if strings.HasPrefix(val, EncryptedValuePrefix) {
+		return MaskedValue, nil
+	}
+
+	return val, nil
+}
+
+func (evp *EncryptedValuesProvider) Label() string {
+	return "encrypted:" + ProviderLabel(evp.base)
+}
+
+func (evp *EncryptedValuesProvider) DecoratedProvider() ValuesProvider {
+	return evp.base
+}
+
+// Decorate wraps vp to decrypt its values with the same key
+func (evp *EncryptedValuesProvider) Decorate(vp ValuesProvider) ValuesProvider {
+	return &EncryptedValuesProvider{
+		base: vp,
+		key:  evp.key,
+	}
+}
+
+// KeyStyle gives the key style of the wrapped values provider
+func (evp *EncryptedValuesProvider) KeyStyle() KeyStyle {
+	return ProviderKeyStyle(evp.base)
+}
+
+// HasStringValues tells if the wrapped values provider gives plain string values, see StringValuesProvider
+func (evp *EncryptedValuesProvider) HasStringValues() bool {
+	svp, ok := evp.base.(StringValuesProvider)
+	return ok && svp.HasStringValues()
+}
@@ -0,0 +1,211 @@
+package options
+
+import (
+	"bytes"
+	"encoding/base64"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	io2 "github.com/breathbath/go_utils/v3/pkg/io"
+	"github.com/breathbath/go_utils/v3/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptionKeys(t *testing.T) {
+	encodedKey, err := GenerateEncryptionKey()
+	require.NoError(t, err)
+
+	keyFilePath := filepath.Join(t.TempDir(), "config.key")
+	err = os.WriteFile(keyFilePath, []byte(encodedKey+"\n"), 0600)
+	require.NoError(t, err)
+
+	keyFromFile, err := EncryptionKeyFromFile(keyFilePath)
+	assert.NoError(t, err)
+	assert.Len(t, keyFromFile, 32)
+
+	_, err = EncryptionKeyFromEnv(DefaultEncryptionKeyEnv)
+	assert.EqualError(t, err, "encryption key is not set in CONFIG_ENCRYPTION_KEY env variable")
+
+	key, err := ReadEncryptionKey(DefaultEncryptionKeyEnv, keyFilePath)
+	assert.NoError(t, err)
+	assert.Equal(t, keyFromFile, key)
+
+	otherEncodedKey, err := GenerateEncryptionKey()
+	require.NoError(t, err)
+
+	err = os.Setenv(DefaultEncryptionKeyEnv, otherEncodedKey)
+	require.NoError(t, err)
+	defer func() {
+		err = os.Unsetenv(DefaultEncryptionKeyEnv)
+		if err != nil {
+			log.Println(err.Error())
+		}
+	}()
+
+	key, err = ReadEncryptionKey(DefaultEncryptionKeyEnv, keyFilePath)
+	assert.NoError(t, err)
+	assert.Equal(t, otherEncodedKey, base64.StdEncoding.EncodeToString(key))
+
+	_, err = EncryptionKeyFromFile(filepath.Join(t.TempDir(), "missing.key"))
+	assert.Error(t, err)
+
+	err = os.Setenv(DefaultEncryptionKeyEnv, "not base64")
+	require.NoError(t, err)
+	_, err = ReadEncryptionKey(DefaultEncryptionKeyEnv, "")
+	assert.Error(t, err)
+	if err != nil {
+		assert.Contains(t, err.Error(), "invalid base64 encoding of the encryption key")
+	}
+
+	err = os.Setenv(DefaultEncryptionKeyEnv, base64.StdEncoding.EncodeToString([]byte("short")))
+	require.NoError(t, err)
+	_, err = EncryptionKeyFromEnv(DefaultEncryptionKeyEnv)
+	assert.EqualError(t, err, "invalid encryption key size 5, 16, 24 or 32 bytes are expected")
+}
+
+func TestEncryptValue(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	encrypted, err := EncryptValue("s3cr3t", key)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encrypted, EncryptedValuePrefix))
+	assert.NotContains(t, encrypted, "s3cr3t")
+
+	decrypted, err := DecryptValue(encrypted, key)
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t", decrypted)
+
+	plain, err := DecryptValue("plain", key)
+	assert.NoError(t, err)
+	assert.Equal(t, "plain", plain)
+
+	_, err = DecryptValue(EncryptedValuePrefix+"!!!", key)
+	assert.Error(t, err)
+
+	_, err = EncryptValue("s3cr3t", []byte("short"))
+	assert.Error(t, err)
+}
+
+func TestEncryptedValuesProvider(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	otherKey := []byte("fedcba9876543210")
+
+	encryptedPass, err := EncryptValue("s3cr3t", key)
+	require.NoError(t, err)
+	encryptedToken, err := EncryptValue("t0k3n", key)
+	require.NoError(t, err)
+	foreignVal, err := EncryptValue("foreign", otherKey)
+	require.NoError(t, err)
+
+	jsonProvider, err := NewJSONValuesProvider(strings.NewReader(`{
+		"db": {"host": "localhost", "pass": "` + encryptedPass + `"},
+		"items": ["` + encryptedToken + `", "plain"],
+		"foreign": "` + foreignVal + `",
+		"port": 5432
+	}`))
+	require.NoError(t, err)
+
+	evp, err := NewEncryptedValuesProvider(jsonProvider, key)
+	require.NoError(t, err)
+	pb := New(evp)
+
+	pass, err := pb.ReadRequiredString("db.pass")
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t", pass)
+	assert.Equal(t, "localhost", pb.ReadString("db.host", ""))
+	assert.Equal(t, []string{"t0k3n", "plain"}, pb.ReadStrings("items"))
+	assert.Equal(t, 5432, pb.ReadInt("port", 0))
+
+	_, err = pb.ReadRequiredString("foreign")
+	assert.EqualError(t, err, "cannot decrypt option foreign: cipher: message authentication failed")
+
+	logger := &channelLoggerMock{messages: make(chan string, 10)}
+	io2.SetLogger(logger)
+	defer io2.SetLogger(io2.DefaultLogger{})
+
+	assert.Equal(t, "default", pb.ReadString("foreign", "default"))
+	assert.Contains(t, <-logger.messages, "cannot decrypt option foreign")
+
+	kvs := evp.ToKeyValues()
+	assert.Equal(t, map[string]interface{}{"host": "localhost", "pass": types.NewSecret("s3cr3t")}, kvs["db"])
+	assert.Equal(t, []interface{}{types.NewSecret("t0k3n"), "plain"}, kvs["items"])
+	assert.NotContains(t, kvs, "foreign")
+	assert.Contains(t, <-logger.messages, "cannot decrypt option foreign: "+
+		"cipher: message authentication failed, encrypted provider json skips option foreign")
+
+	b := &bytes.Buffer{}
+	err = evp.Dump(b)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		`{"db":{"host":"localhost","pass":"******"},"foreign":"******","items":["******","plain"],"port":5432}`+"\n",
+		b.String(),
+	)
+	assert.Equal(t, "encrypted:json", evp.Label())
+
+	_, err = NewEncryptedValuesProvider(jsonProvider, []byte("short"))
+	assert.EqualError(t, err, "invalid encryption key size 5, 16, 24 or 32 bytes are expected")
+}
+
+func TestEncryptedValuesAreMaskedInDumpsOfBagsAndComposites(t *testing.T) {
+	key := make([]byte, 32)
+	encryptedPass, err := EncryptValue("hunter2", key)
+	require.NoError(t, err)
+
+	evp, err := NewEncryptedValuesProvider(NewMapValuesProvider(map[string]interface{}{
+		"db_pw":    encryptedPass,
+		"dsn_main": encryptedPass,
+		"host":     "localhost",
+	}), key)
+	require.NoError(t, err)
+
+	pb := New(evp)
+	assert.Equal(t, "hunter2", pb.ReadString("dsn_main", ""))
+
+	vpc := NewValuesProviderComposite(evp)
+	dumps := map[string]func(w *bytes.Buffer) error{
+		"composite": func(w *bytes.Buffer) error {
+			return vpc.Dump(w)
+		},
+		"bag": func(w *bytes.Buffer) error {
+			return pb.Dump(w)
+		},
+		"sources": func(w *bytes.Buffer) error {
+			return New(vpc).DumpWithSources(w)
+		},
+	}
+	for _, format := range []DumpFormat{DumpFormatJSON, DumpFormatEnv, DumpFormatYAML, DumpFormatTable} {
+		dumpFormat := format
+		dumps[string(dumpFormat)] = func(w *bytes.Buffer) error {
+			return pb.DumpFormatted(w, dumpFormat)
+		}
+	}
+
+	for name, dump := range dumps {
+		b := &bytes.Buffer{}
+		err = dump(b)
+		require.NoError(t, err, name)
+		assert.NotContains(t, b.String(), "hunter2", name)
+		assert.Contains(t, b.String(), "******", name)
+		assert.Contains(t, b.String(), "localhost", name)
+	}
+
+	otherEncryptedPass, err := EncryptValue("hunter3", key)
+	require.NoError(t, err)
+	changedEvp, err := NewEncryptedValuesProvider(NewMapValuesProvider(map[string]interface{}{
+		"db_pw":    otherEncryptedPass,
+		"dsn_main": encryptedPass,
+		"host":     "localhost",
+	}), key)
+	require.NoError(t, err)
+
+	diff := DiffProviders(evp, changedEvp)
+	assert.Equal(t, []string{"db_pw"}, diff.Keys())
+	diffText := &bytes.Buffer{}
+	require.NoError(t, diff.WriteText(diffText))
+	assert.NotContains(t, diffText.String(), "hunter")
+}
@@ -0,0 +1,90 @@
+package options
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+)
+
+/*
+EnvOverridesValuesProvider reads dotted option names from env variables named by EnvNameFromKey e.g. db.host
+is read from DB_HOST, so env variables can override nested options of file providers in a composite,
+ToKeyValues gives only the env values of the options which exist in the reference provider,
+so dumps of the composite don't contain unrelated env variables
+*/
+type EnvOverridesValuesProvider struct {
+	reference ValuesProvider
+}
+
+// NewEnvOverridesValuesProvider creates a provider which overrides options of reference with env variables
+func NewEnvOverridesValuesProvider(reference ValuesProvider) *EnvOverridesValuesProvider {
+	if reference == nil {
+		reference = &NullValuesProvider{}
+	}
+
+	return &EnvOverridesValuesProvider{reference: reference}
+}
+
+// Read reads the env variable of name, an object option gives its overridden nested options e.g. {"host": "envhost"}
+// for db if DB_HOST is set
+func (eovp *EnvOverridesValuesProvider) Read(name string) (val interface{}, found bool) {
+	val, found = os.LookupEnv(EnvNameFromKey(name))
+	if found {
+		return val, true
+	}
+
+	return NewMapValuesProvider(eovp.ToKeyValues()).Read(name)
+}
+
+// ToKeyValues gives nested env values of the options of the reference provider
+func (eovp *EnvOverridesValuesProvider) ToKeyValues() map[string]interface{} {
+	return envOverrides("", eovp.reference.ToKeyValues())
+}
+
+func envOverrides(prefix string, kvs map[string]interface{}) map[string]interface{} {
+	res := map[string]interface{}{}
+	for key, val := range kvs {
+		fullKey := key
+		if prefix != "" {
+			fullKey = prefix + KeySeparator + key
+		}
+
+		envVal, found := os.LookupEnv(EnvNameFromKey(fullKey))
+		if found {
+			res[key] = envVal
+			continue
+		}
+
+		nestedKvs, isObject := val.(map[string]interface{})
+		if !isObject {
+			continue
+		}
+
+		nestedOverrides := envOverrides(fullKey, nestedKvs)
+		if len(nestedOverrides) > 0 {
+			res[key] = nestedOverrides
+		}
+	}
+
+	return res
+}
+
+// Dump outputs overridden values as json, sensitive values are masked by CurrentRedactor
+func (eovp *EnvOverridesValuesProvider) Dump(w io.Writer) (err error) {
+	data := CurrentRedactor().Redact(eovp.ToKeyValues())
+	jsonEncoder := json.NewEncoder(w)
+	return jsonEncoder.Encode(data)
+}
+
+// DumpFormatted outputs values in the given format, see DumpFormatted
+func (eovp *EnvOverridesValuesProvider) DumpFormatted(w io.Writer, format DumpFormat) error {
+	return DumpFormatted(eovp, w, format)
+}
+
+func (eovp *EnvOverridesValuesProvider) Label() string {
+	return "env"
+}
+
+func (eovp *EnvOverridesValuesProvider) HasStringValues() bool {
+	return true
+}
@@ -0,0 +1,52 @@
+package options
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvOverridesValuesProvider(t *testing.T) {
+	t.Setenv("DB_HOST", "envhost")
+	t.Setenv("DB_PASSWORD", "envpass")
+	t.Setenv("MAX_CONNS", "20")
+
+	reference := NewMapValuesProvider(map[string]interface{}{
+		"db":       map[string]interface{}{"host": "localhost", "port": 5432, "password": "pass"},
+		"maxConns": 10,
+		"name":     "app",
+	})
+	eovp := NewEnvOverridesValuesProvider(reference)
+
+	val, found := eovp.Read("db.host")
+	assert.True(t, found)
+	assert.Equal(t, "envhost", val)
+
+	val, found = eovp.Read("maxConns")
+	assert.True(t, found)
+	assert.Equal(t, "20", val)
+
+	val, found = eovp.Read("db")
+	assert.True(t, found)
+	assert.Equal(t, map[string]interface{}{"host": "envhost", "password": "envpass"}, val)
+
+	_, found = eovp.Read("db.port")
+	assert.False(t, found)
+
+	assert.Equal(
+		t,
+		map[string]interface{}{
+			"db":       map[string]interface{}{"host": "envhost", "password": "envpass"},
+			"maxConns": "20",
+		},
+		eovp.ToKeyValues(),
+	)
+
+	b := &bytes.Buffer{}
+	err := eovp.Dump(b)
+	require.NoError(t, err)
+	assert.Equal(t, `{"db":{"host":"envhost","password":"******"},"maxConns":"20"}`+"\n", b.String())
+	assert.Equal(t, "env", eovp.Label())
+}
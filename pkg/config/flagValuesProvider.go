@@ -0,0 +1,224 @@
+package options
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// FlagNameFromKey converts an option name to a flag name e.g. db.host, DB_HOST or dbHost to db-host
+func FlagNameFromKey(key string) string {
+	res := strings.Builder{}
+	for i, r := range key {
+		switch {
+		case r == '.' || r == '_':
+			res.WriteRune('-')
+		case r >= 'A' && r <= 'Z':
+			if i > 0 && isLowerOrDigit(key[i-1]) {
+				res.WriteRune('-')
+			}
+			res.WriteRune(r - 'A' + 'a')
+		default:
+			res.WriteRune(r)
+		}
+	}
+
+	return res.String()
+}
+
+func isLowerOrDigit(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9')
+}
+
+// StringsFlag is a flag.Value which collects all values of a repeated flag e.g. -host a.com -host b.com
+type StringsFlag []string
+
+func (sf *StringsFlag) String() string {
+	if sf == nil {
+		return ""
+	}
+
+	return strings.Join(*sf, ",")
+}
+
+func (sf *StringsFlag) Set(val string) error {
+	*sf = append(*sf, val)
+	return nil
+}
+
+func (sf *StringsFlag) Get() interface{} {
+	return []string(*sf)
+}
+
+// FlagValuesProvider gives values of command line flags which were set by the user, option names are converted
+// to flag names with FlagNameFromKey, so db.host, DB_HOST and db-host are all read from the -db-host flag,
+// ToKeyValues gives nested config keys of the flags e.g. {"db":{"host":"x"}} for -db-host x
+type FlagValuesProvider struct {
+	flagSet *flag.FlagSet
+	values  map[string]interface{}
+	args    []string
+}
+
+// NewFlagValuesProvider gives values of the flags which were set in the parsed flagSet, values of flags implementing
+// flag.Getter are given as they are returned by Get e.g. bool for flag.Bool or []string for StringsFlag
+func NewFlagValuesProvider(flagSet *flag.FlagSet) *FlagValuesProvider {
+	return &FlagValuesProvider{
+		flagSet: flagSet,
+	}
+}
+
+/*
+NewFlagValuesProviderFromArgs parses os.Args style arguments without the program name, e.g. os.Args[1:],
+the following forms are supported:
+
+	-name value, --name value, -name=value, --name=value
+
+a flag without value or followed by another flag gets "true", a repeated flag gives []string with all values,
+a negative number is a value and not a flag e.g. --offset -5, arguments which are not flags and all arguments
+after -- are available in Args; boolFlags are names of switches which never take the next argument as their value,
+so --verbose file.txt gives verbose "true" and file.txt in Args, their values can still be set as --verbose=false
+*/
+func NewFlagValuesProviderFromArgs(args []string, boolFlags ...string) (*FlagValuesProvider, error) {
+	fvp := &FlagValuesProvider{
+		values: map[string]interface{}{},
+		args:   []string{},
+	}
+
+	isBoolFlag := make(map[string]bool, len(boolFlags))
+	for _, boolFlag := range boolFlags {
+		isBoolFlag[FlagNameFromKey(boolFlag)] = true
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			fvp.args = append(fvp.args, args[i+1:]...)
+			break
+		}
+
+		if len(arg) < 2 || arg[0] != '-' || isNumber(arg) {
+			fvp.args = append(fvp.args, arg)
+			continue
+		}
+
+		nameAndValue := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		name, val := nameAndValue, "true"
+		if eqPos := strings.Index(nameAndValue, "="); eqPos >= 0 {
+			name, val = nameAndValue[:eqPos], nameAndValue[eqPos+1:]
+		} else if i+1 < len(args) && !isBoolFlag[FlagNameFromKey(name)] &&
+			(!strings.HasPrefix(args[i+1], "-") || isNumber(args[i+1])) {
+			val = args[i+1]
+			i++
+		}
+
+		if name == "" || strings.HasPrefix(name, "-") {
+			return nil, fmt.Errorf("bad flag syntax: %s", arg)
+		}
+
+		fvp.addValue(FlagNameFromKey(name), val)
+	}
+
+	return fvp, nil
+}
+
+// isNumber checks if arg is a number like -5 or -0.5, but not -inf or -nan which are rather flag names
+func isNumber(arg string) bool {
+	_, err := strconv.ParseFloat(arg, 64)
+	return err == nil && strings.ContainsAny(arg, "0123456789")
+}
+
+func (fvp *FlagValuesProvider) addValue(name, val string) {
+	existingVal, found := fvp.values[name]
+	if !found {
+		fvp.values[name] = val
+		return
+	}
+
+	switch typedVal := existingVal.(type) {
+	case []string:
+		fvp.values[name] = append(typedVal, val)
+	default:
+		fvp.values[name] = []string{fmt.Sprint(typedVal), val}
+	}
+}
+
+// Args gives the arguments which are not flags
+func (fvp *FlagValuesProvider) Args() []string {
+	if fvp.flagSet != nil {
+		return fvp.flagSet.Args()
+	}
+
+	return fvp.args
+}
+
+// Read reads the flag of name, an object option gives its nested flags e.g. {"host": "x"} for db if -db-host is set
+func (fvp *FlagValuesProvider) Read(name string) (val interface{}, found bool) {
+	flagName := FlagNameFromKey(name)
+	if fvp.flagSet == nil {
+		val, found = fvp.values[flagName]
+	} else {
+		fvp.flagSet.Visit(func(f *flag.Flag) {
+			if f.Name == flagName {
+				val, found = flagValue(f), true
+			}
+		})
+	}
+
+	if found {
+		return val, true
+	}
+
+	return NewMapValuesProvider(fvp.ToKeyValues()).Read(name)
+}
+
+func flagValue(f *flag.Flag) interface{} {
+	if getter, ok := f.Value.(flag.Getter); ok {
+		return getter.Get()
+	}
+
+	return f.Value.String()
+}
+
+// ToKeyValues gives values of the set flags under nested config keys, parts of a flag name are separated by dashes
+// e.g. db-host gives {"db":{"host":"x"}}
+func (fvp *FlagValuesProvider) ToKeyValues() map[string]interface{} {
+	kvs := map[string]interface{}{}
+	if fvp.flagSet == nil {
+		for k, v := range fvp.values {
+			kvs[keyFromFlagName(k)] = v
+		}
+	} else {
+		fvp.flagSet.Visit(func(f *flag.Flag) {
+			kvs[keyFromFlagName(f.Name)] = flagValue(f)
+		})
+	}
+
+	return nestKeyValues(kvs)
+}
+
+func keyFromFlagName(flagName string) string {
+	return strings.ReplaceAll(flagName, "-", KeySeparator)
+}
+
+// Dump outputs values of the set flags as json, sensitive values are masked by CurrentRedactor
+func (fvp *FlagValuesProvider) Dump(w io.Writer) (err error) {
+	data := CurrentRedactor().Redact(fvp.ToKeyValues())
+	jsonEncoder := json.NewEncoder(w)
+	return jsonEncoder.Encode(data)
+}
+
+// DumpFormatted outputs values in the given format, see DumpFormatted
+func (fvp *FlagValuesProvider) DumpFormatted(w io.Writer, format DumpFormat) error {
+	return DumpFormatted(fvp, w, format)
+}
+
+func (fvp *FlagValuesProvider) Label() string {
+	return "flags"
+}
+
+func (fvp *FlagValuesProvider) HasStringValues() bool {
+	return true
+}
@@ -0,0 +1,174 @@
+package options
+
+import (
+	"bytes"
+	"flag"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlagNameFromKey(t *testing.T) {
+	testCases := map[string]string{
+		"db.host":     "db-host",
+		"DB_HOST":     "db-host",
+		"dbHost":      "db-host",
+		"db-host":     "db-host",
+		"port":        "port",
+		"server2Port": "server2-port",
+		"HTTPS_PROXY": "https-proxy",
+	}
+
+	for key, expectedName := range testCases {
+		assert.Equal(t, expectedName, FlagNameFromKey(key), key)
+	}
+}
+
+func TestFlagValuesProviderWithFlagSet(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("db-host", "localhost", "")
+	fs.Int("db-port", 5432, "")
+	fs.Bool("verbose", false, "")
+	hosts := &StringsFlag{}
+	fs.Var(hosts, "host", "")
+
+	err := fs.Parse([]string{"-db-host", "remote", "-verbose", "-host", "a.com", "--host=b.com", "file.txt"})
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	fvp := NewFlagValuesProvider(fs)
+
+	val, found := fvp.Read("db.host")
+	assert.True(t, found)
+	assert.Equal(t, "remote", val)
+
+	val, found = fvp.Read("DB_HOST")
+	assert.True(t, found)
+	assert.Equal(t, "remote", val)
+
+	_, found = fvp.Read("db.port")
+	assert.False(t, found)
+
+	pb := New(fvp)
+	assert.True(t, pb.ReadBool("verbose", false))
+	assert.Equal(t, []string{"a.com", "b.com"}, pb.ReadStrings("host"))
+	assert.Equal(t, 1, pb.ReadInt("db.port", 1))
+	assert.Equal(t, []string{"file.txt"}, fvp.Args())
+
+	assert.Equal(
+		t,
+		map[string]interface{}{
+			"db":      map[string]interface{}{"host": "remote"},
+			"verbose": true,
+			"host":    []string{"a.com", "b.com"},
+		},
+		fvp.ToKeyValues(),
+	)
+
+	val, found = fvp.Read("db")
+	assert.True(t, found)
+	assert.Equal(t, map[string]interface{}{"host": "remote"}, val)
+	assert.Equal(t, "flags", fvp.Label())
+}
+
+func TestFlagValuesProviderFromArgs(t *testing.T) {
+	fvp, err := NewFlagValuesProviderFromArgs([]string{
+		"--db-host", "remote",
+		"-DB_USER=admin",
+		"--verbose",
+		"--tag", "one", "--tag=two", "-tag", "three",
+		"input.txt",
+		"--dry-run",
+		"--",
+		"--not-a-flag",
+	})
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	pb := New(fvp)
+	assert.Equal(t, "remote", pb.ReadString("db.host", ""))
+	assert.Equal(t, "admin", pb.ReadString("db.user", ""))
+	assert.True(t, pb.ReadBool("verbose", false))
+	assert.True(t, pb.ReadBool("dryRun", false))
+	assert.Equal(t, []string{"one", "two", "three"}, pb.ReadStrings("tag"))
+	assert.Equal(t, []string{"input.txt", "--not-a-flag"}, fvp.Args())
+
+	_, found := fvp.Read("not-a-flag")
+	assert.False(t, found)
+
+	assert.Equal(
+		t,
+		map[string]interface{}{
+			"db":      map[string]interface{}{"host": "remote", "user": "admin"},
+			"verbose": "true",
+			"dry":     map[string]interface{}{"run": "true"},
+			"tag":     []string{"one", "two", "three"},
+		},
+		fvp.ToKeyValues(),
+	)
+
+	fvp, err = NewFlagValuesProviderFromArgs([]string{"--offset", "-5", "--ratio", "-0.5", "-3", "--inf", "-nan"})
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+	pb = New(fvp)
+	assert.Equal(t, -5, pb.ReadInt("offset", 0))
+	assert.Equal(t, -0.5, pb.ReadFloat64("ratio", 0))
+	assert.True(t, pb.ReadBool("inf", false))
+	assert.True(t, pb.ReadBool("nan", false))
+	assert.Equal(t, []string{"-3"}, fvp.Args())
+
+	fvp, err = NewFlagValuesProviderFromArgs(
+		[]string{"--verbose", "file.txt", "--dry-run", "out.txt", "--debug=false", "--level", "2"},
+		"verbose", "dryRun", "debug",
+	)
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+	pb = New(fvp)
+	assert.True(t, pb.ReadBool("verbose", false))
+	assert.True(t, pb.ReadBool("dry.run", false))
+	assert.False(t, pb.ReadBool("debug", true))
+	assert.Equal(t, 2, pb.ReadInt("level", 0))
+	assert.Equal(t, []string{"file.txt", "out.txt"}, fvp.Args())
+
+	_, err = NewFlagValuesProviderFromArgs([]string{"---host"})
+	assert.EqualError(t, err, "bad flag syntax: ---host")
+
+	_, err = NewFlagValuesProviderFromArgs([]string{"--=value"})
+	assert.EqualError(t, err, "bad flag syntax: --=value")
+}
+
+func TestFlagValuesProviderPrecedence(t *testing.T) {
+	err := os.Setenv("DB_HOST", "envHost")
+	assert.NoError(t, err)
+	defer func() {
+		err = os.Unsetenv("DB_HOST")
+		if err != nil {
+			log.Println(err.Error())
+		}
+	}()
+
+	fvp, err := NewFlagValuesProviderFromArgs([]string{"--db-host=flagHost", "--db-password=secret"})
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	pb := New(NewValuesProviderComposite(fvp, &EnvValuesProvider{}))
+	assert.Equal(t, "flagHost", pb.ReadString("DB_HOST", ""))
+
+	b := &bytes.Buffer{}
+	err = fvp.Dump(b)
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), `"db":{"host":"flagHost"`)
+	assert.NotContains(t, b.String(), "secret")
+}
@@ -0,0 +1,274 @@
+package options
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/breathbath/go_utils/v3/pkg/conv"
+	io2 "github.com/breathbath/go_utils/v3/pkg/io"
+	"github.com/breathbath/go_utils/v3/pkg/rest"
+)
+
+// HTTPLoggingTopic is used as topic for outputs of remote config fetches
+const HTTPLoggingTopic = "config_http"
+
+// HTTPOptions defines how remote config values are fetched
+type HTTPOptions struct {
+	// URL of the endpoint which gives config values as a json object
+	URL string
+	// Headers are sent with each request e.g. Authorization
+	Headers map[string]string
+	// CacheFilePath is an optional file where the last fetched values are stored to be used at startup
+	// if the endpoint is unreachable
+	CacheFilePath string
+	// Timeout limits each request, 10 seconds are used if not set
+	Timeout time.Duration
+}
+
+/*
+HTTPValuesProvider gives values of a json object fetched from an http endpoint with rest.JSONClient,
+the endpoint can be polled with Watch, requests contain If-None-Match with the last received ETag, so unchanged values
+are not transferred again if the endpoint answers with 304 Not Modified,
+a failed fetch keeps the last successfully fetched values
+*/
+type HTTPValuesProvider struct {
+	opts        HTTPOptions
+	client      *rest.JSONClient
+	fetchMx     sync.Mutex
+	mx          sync.RWMutex
+	vals        *MapValuesProvider
+	etag        string
+	subscribers []func(diff *Diff)
+}
+
+/*
+NewHTTPValuesProvider fetches values from opts.URL, if the fetch fails and opts.CacheFilePath is set,
+values are read from the cache file, the error is given if no values could be loaded
+*/
+func NewHTTPValuesProvider(ctx context.Context, opts HTTPOptions) (*HTTPValuesProvider, error) {
+	if opts.Timeout == 0 {
+		opts.Timeout = time.Second * 10
+	}
+
+	hvp := &HTTPValuesProvider{
+		opts:   opts,
+		client: rest.NewJSONClient(),
+		vals:   NewMapValuesProvider(map[string]interface{}{}),
+	}
+
+	_, err := hvp.Fetch(ctx)
+	if err == nil {
+		return hvp, nil
+	}
+
+	if opts.CacheFilePath == "" {
+		return nil, err
+	}
+
+	io2.OutputError(err, HTTPLoggingTopic, "will read config from the cache file %s", opts.CacheFilePath)
+	cacheErr := hvp.loadCacheFile()
+	if cacheErr != nil {
+		return nil, fmt.Errorf("%v, failed to read the cache file: %v", err, cacheErr)
+	}
+
+	return hvp, nil
+}
+
+func (hvp *HTTPValuesProvider) loadCacheFile() error {
+	data, err := os.ReadFile(hvp.opts.CacheFilePath)
+	if err != nil {
+		return err
+	}
+
+	params, err := parseJSONValues(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %v", hvp.opts.CacheFilePath, err)
+	}
+
+	hvp.mx.Lock()
+	hvp.vals = NewMapValuesProvider(params)
+	hvp.mx.Unlock()
+
+	return nil
+}
+
+// writeCacheFile replaces the cache file with a fully written temp file, so a crash can't leave a broken cache
+func (hvp *HTTPValuesProvider) writeCacheFile(data []byte) error {
+	tempFile, err := os.CreateTemp(filepath.Dir(hvp.opts.CacheFilePath), filepath.Base(hvp.opts.CacheFilePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = tempFile.Write(data)
+	closeErr := tempFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tempFile.Name(), hvp.opts.CacheFilePath)
+	}
+
+	if err != nil {
+		removeErr := os.Remove(tempFile.Name())
+		if removeErr != nil {
+			io2.OutputError(removeErr, HTTPLoggingTopic, "failed to remove the temp cache file %s of %s", tempFile.Name(), hvp.opts.URL)
+		}
+	}
+
+	return err
+}
+
+// OnChange registers a callback which is called with the sorted flat names of added, removed or changed options
+// after each fetch which changed values
+func (hvp *HTTPValuesProvider) OnChange(callback func(changedKeys []string)) {
+	hvp.OnDiff(func(diff *Diff) {
+		callback(diff.Keys())
+	})
+}
+
+// OnDiff registers a callback which is called with old and new values of changed options after each fetch
+func (hvp *HTTPValuesProvider) OnDiff(callback func(diff *Diff)) {
+	hvp.mx.Lock()
+	defer hvp.mx.Unlock()
+
+	hvp.subscribers = append(hvp.subscribers, callback)
+}
+
+// Watch fetches values every interval in background until ctx is done, failed fetches are reported
+// with io.OutputError and the last successfully fetched values are kept, the returned channel is closed
+// when watching is stopped
+func (hvp *HTTPValuesProvider) Watch(ctx context.Context, interval time.Duration) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_, err := hvp.Fetch(ctx)
+				if err != nil {
+					io2.OutputError(err, HTTPLoggingTopic, "failed to fetch config from %s", hvp.opts.URL)
+				}
+			}
+		}
+	}()
+
+	return done
+}
+
+// Fetch requests values from the endpoint and swaps them if they were changed, it gives the names of changed options,
+// on failure the current values are kept
+func (hvp *HTTPValuesProvider) Fetch(ctx context.Context) (changedKeys []string, err error) {
+	hvp.fetchMx.Lock()
+	defer hvp.fetchMx.Unlock()
+
+	headers := make(map[string]string, len(hvp.opts.Headers)+1)
+	for key, val := range hvp.opts.Headers {
+		headers[key] = val
+	}
+
+	hvp.mx.RLock()
+	if hvp.etag != "" {
+		headers["If-None-Match"] = hvp.etag
+	}
+	hvp.mx.RUnlock()
+
+	reqCtx, cancel := context.WithTimeout(ctx, hvp.opts.Timeout)
+	defer cancel()
+
+	body, resp, err := hvp.client.Get(reqCtx, &rest.RequestContext{
+		TargetURL:        hvp.opts.URL,
+		Headers:          headers,
+		LoggingTopic:     HTTPLoggingTopic,
+		HideResponseBody: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch config from %s: %v", hvp.opts.URL, err)
+	}
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, nil
+	}
+
+	params, err := parseJSONValues(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config from %s: %v", hvp.opts.URL, err)
+	}
+
+	if hvp.opts.CacheFilePath != "" {
+		cacheErr := hvp.writeCacheFile(body)
+		if cacheErr != nil {
+			io2.OutputError(cacheErr, HTTPLoggingTopic, "failed to write the cache file %s", hvp.opts.CacheFilePath)
+		}
+	}
+
+	newVals := &MapValuesProvider{
+		parameters: conv.ConvertMapToSyncMap(params),
+	}
+
+	hvp.mx.Lock()
+	oldVals := hvp.vals
+	hvp.vals = newVals
+	hvp.etag = resp.Header.Get("ETag")
+	subscribers := make([]func(diff *Diff), len(hvp.subscribers))
+	copy(subscribers, hvp.subscribers)
+	hvp.mx.Unlock()
+
+	diff := DiffKeyValues(
+		conv.ConvertSyncMapToMap(oldVals.parameters),
+		conv.ConvertSyncMapToMap(newVals.parameters),
+	)
+	if diff.IsEmpty() {
+		return nil, nil
+	}
+
+	for _, subscriber := range subscribers {
+		subscriber(diff)
+	}
+
+	return diff.Keys(), nil
+}
+
+// URL gives the config endpoint
+func (hvp *HTTPValuesProvider) URL() string {
+	return hvp.opts.URL
+}
+
+func (hvp *HTTPValuesProvider) currentValues() *MapValuesProvider {
+	hvp.mx.RLock()
+	defer hvp.mx.RUnlock()
+
+	return hvp.vals
+}
+
+func (hvp *HTTPValuesProvider) Read(name string) (val interface{}, found bool) {
+	return hvp.currentValues().Read(name)
+}
+
+func (hvp *HTTPValuesProvider) Dump(w io.Writer) (err error) {
+	return hvp.currentValues().Dump(w)
+}
+
+// DumpFormatted outputs values in the given format, see DumpFormatted
+func (hvp *HTTPValuesProvider) DumpFormatted(w io.Writer, format DumpFormat) error {
+	return DumpFormatted(hvp, w, format)
+}
+
+func (hvp *HTTPValuesProvider) Label() string {
+	return "http:" + hvp.opts.URL
+}
+
+func (hvp *HTTPValuesProvider) ToKeyValues() map[string]interface{} {
+	return hvp.currentValues().ToKeyValues()
+}
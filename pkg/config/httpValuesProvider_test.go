@@ -0,0 +1,190 @@
+package options
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	io2 "github.com/breathbath/go_utils/v3/pkg/io"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type silentLoggerMock struct{}
+
+func (slm silentLoggerMock) OutputMessageType(messageType, topic, msg string, args ...interface{}) {}
+
+type configServerMock struct {
+	mx          sync.Mutex
+	body        string
+	etag        string
+	statusCode  int
+	requests    int
+	notModified int
+	headers     []http.Header
+}
+
+func (csm *configServerMock) set(body, etag string, statusCode int) {
+	csm.mx.Lock()
+	defer csm.mx.Unlock()
+
+	csm.body, csm.etag, csm.statusCode = body, etag, statusCode
+}
+
+func (csm *configServerMock) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	csm.mx.Lock()
+	defer csm.mx.Unlock()
+
+	csm.requests++
+	csm.headers = append(csm.headers, r.Header)
+
+	if csm.statusCode != http.StatusOK {
+		rw.WriteHeader(csm.statusCode)
+		return
+	}
+
+	if csm.etag != "" && r.Header.Get("If-None-Match") == csm.etag {
+		csm.notModified++
+		rw.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	rw.Header().Set("ETag", csm.etag)
+	_, err := rw.Write([]byte(csm.body))
+	if err != nil {
+		rw.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func TestHTTPValuesProviderFetch(t *testing.T) {
+	io2.SetLogger(silentLoggerMock{})
+	defer io2.SetLogger(io2.DefaultLogger{})
+
+	serverMock := &configServerMock{}
+	serverMock.set(`{"color":"red","db":{"host":"a"}}`, `"v1"`, http.StatusOK)
+	srv := httptest.NewServer(serverMock)
+	defer srv.Close()
+
+	cacheFilePath := filepath.Join(t.TempDir(), "config.cache.json")
+	hvp, err := NewHTTPValuesProvider(context.Background(), HTTPOptions{
+		URL:           srv.URL,
+		Headers:       map[string]string{"Authorization": "Bearer token"},
+		CacheFilePath: cacheFilePath,
+	})
+	require.NoError(t, err)
+
+	pb := New(hvp)
+	assert.Equal(t, "red", pb.ReadString("color", ""))
+	assert.Equal(t, "a", pb.ReadString("db.host", ""))
+	assert.Equal(t, "http:"+srv.URL, hvp.Label())
+
+	cacheData, err := os.ReadFile(cacheFilePath)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"color":"red","db":{"host":"a"}}`, string(cacheData))
+
+	notifiedKeys := [][]string{}
+	hvp.OnChange(func(changedKeys []string) {
+		notifiedKeys = append(notifiedKeys, changedKeys)
+	})
+
+	changedKeys, err := hvp.Fetch(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, changedKeys)
+	assert.Equal(t, 1, serverMock.notModified)
+	assert.Equal(t, `"v1"`, serverMock.headers[1].Get("If-None-Match"))
+	assert.Equal(t, "Bearer token", serverMock.headers[1].Get("Authorization"))
+
+	serverMock.set(`{"color":"blue","db":{"host":"a"}}`, `"v2"`, http.StatusOK)
+	changedKeys, err = hvp.Fetch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"color"}, changedKeys)
+	assert.Equal(t, [][]string{{"color"}}, notifiedKeys)
+	assert.Equal(t, "blue", pb.ReadString("color", ""))
+
+	serverMock.set("", "", http.StatusInternalServerError)
+	_, err = hvp.Fetch(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, "blue", pb.ReadString("color", ""))
+
+	serverMock.set(`{"color":`, `"v3"`, http.StatusOK)
+	_, err = hvp.Fetch(context.Background())
+	assert.EqualError(t, err, "failed to parse config from "+srv.URL+": unexpected EOF")
+	assert.Equal(t, "blue", pb.ReadString("color", ""))
+	assert.Len(t, notifiedKeys, 1)
+
+	cacheData, err = os.ReadFile(cacheFilePath)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"color":"blue","db":{"host":"a"}}`, string(cacheData))
+}
+
+func TestHTTPValuesProviderCacheFallback(t *testing.T) {
+	io2.SetLogger(silentLoggerMock{})
+	defer io2.SetLogger(io2.DefaultLogger{})
+
+	srv := httptest.NewServer(&configServerMock{statusCode: http.StatusOK})
+	unreachableURL := srv.URL
+	srv.Close()
+
+	cacheFilePath := filepath.Join(t.TempDir(), "config.cache.json")
+	err := os.WriteFile(cacheFilePath, []byte(`{"color":"cached"}`), 0600)
+	require.NoError(t, err)
+
+	hvp, err := NewHTTPValuesProvider(context.Background(), HTTPOptions{
+		URL:           unreachableURL,
+		CacheFilePath: cacheFilePath,
+		Timeout:       time.Second,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "cached", New(hvp).ReadString("color", ""))
+
+	_, err = NewHTTPValuesProvider(context.Background(), HTTPOptions{URL: unreachableURL})
+	assert.Error(t, err)
+
+	_, err = NewHTTPValuesProvider(context.Background(), HTTPOptions{
+		URL:           unreachableURL,
+		CacheFilePath: filepath.Join(t.TempDir(), "missing.json"),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read the cache file")
+}
+
+func TestHTTPValuesProviderWatch(t *testing.T) {
+	io2.SetLogger(silentLoggerMock{})
+	defer io2.SetLogger(io2.DefaultLogger{})
+
+	serverMock := &configServerMock{}
+	serverMock.set(`{"color":"red"}`, `"v1"`, http.StatusOK)
+	srv := httptest.NewServer(serverMock)
+	defer srv.Close()
+
+	hvp, err := NewHTTPValuesProvider(context.Background(), HTTPOptions{URL: srv.URL})
+	require.NoError(t, err)
+
+	diffs := make(chan *Diff, 1)
+	hvp.OnDiff(func(diff *Diff) {
+		diffs <- diff
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := hvp.Watch(ctx, time.Millisecond*10)
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	serverMock.set(`{"color":"green"}`, `"v2"`, http.StatusOK)
+
+	select {
+	case diff := <-diffs:
+		assert.Equal(t, []Change{{Key: "color", Type: ChangeChanged, OldValue: "red", NewValue: "green"}}, diff.Changes)
+	case <-time.After(time.Second * 5):
+		assert.Fail(t, "config change was not detected")
+	}
+
+	assert.Equal(t, "green", New(hvp).ReadString("color", ""))
+}
@@ -0,0 +1,243 @@
+package options
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	io2 "github.com/breathbath/go_utils/v3/pkg/io"
+)
+
+const (
+	varStart        = "${"
+	escapedVarStart = "$${"
+	varEnd          = "}"
+	defaultMarker   = ":-"
+)
+
+// VariableLookup gives a value of a referenced variable
+type VariableLookup func(name string) (val string, found bool, err error)
+
+/*
+ExpandVariables replaces ${NAME} references in input with values given by lookup, ${NAME:-default} gives default
+if NAME is not found or empty, default can contain references as well e.g. ${HOST:-${FALLBACK_HOST}},
+$${ is kept as literal ${ e.g. $${NAME} gives ${NAME}
+*/
+func ExpandVariables(input string, lookup VariableLookup) (string, error) {
+	res := strings.Builder{}
+	rest := input
+	for {
+		startPos := strings.Index(rest, "$")
+		if startPos < 0 {
+			res.WriteString(rest)
+			return res.String(), nil
+		}
+
+		res.WriteString(rest[:startPos])
+		rest = rest[startPos:]
+
+		switch {
+		case strings.HasPrefix(rest, escapedVarStart):
+			res.WriteString(varStart)
+			rest = rest[len(escapedVarStart):]
+		case strings.HasPrefix(rest, varStart):
+			endPos := findVariableEnd(rest)
+			if endPos < 0 {
+				return "", fmt.Errorf("unterminated variable reference in %q", input)
+			}
+
+			val, err := expandVariable(rest[len(varStart):endPos], lookup)
+			if err != nil {
+				return "", err
+			}
+			res.WriteString(val)
+			rest = rest[endPos+len(varEnd):]
+		default:
+			res.WriteString("$")
+			rest = rest[1:]
+		}
+	}
+}
+
+// findVariableEnd gives position of the closing brace of the reference in the beginning of input considering nested references
+func findVariableEnd(input string) int {
+	depth := 0
+	for i := 0; i < len(input); i++ {
+		switch {
+		case strings.HasPrefix(input[i:], varStart):
+			depth++
+			i++
+		case input[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+func expandVariable(expression string, lookup VariableLookup) (string, error) {
+	name, defaultVal, hasDefault := expression, "", false
+	if pos := strings.Index(expression, defaultMarker); pos >= 0 {
+		name, defaultVal, hasDefault = expression[:pos], expression[pos+len(defaultMarker):], true
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("empty variable name in ${%s}", expression)
+	}
+
+	val, found, err := lookup(name)
+	if err != nil {
+		return "", err
+	}
+
+	if hasDefault && (!found || val == "") {
+		return ExpandVariables(defaultVal, lookup)
+	}
+
+	return val, nil
+}
+
+/*
+InterpolatingValuesProvider expands ${KEY} and ${KEY:-default} references in string values of the wrapped provider
+with values of other options of the same provider, so a composite should be wrapped to resolve references against all
+combined providers e.g.
+
+	{"db": {"user": "app", "host": "localhost"}, "dsn": "postgres://${db.user}@${db.host}/app"}
+
+gives postgres://app@localhost/app for dsn, referenced values are expanded as well, a reference cycle gives a read error,
+$${ is kept as literal ${
+*/
+type InterpolatingValuesProvider struct {
+	base ValuesProvider
+	// refs resolves references of the values of base, it's the provider which decorated base with Decorate
+	// or nil if references are resolved against base
+	refs *InterpolatingValuesProvider
+}
+
+// NewInterpolatingValuesProvider wraps vp to expand references in its values
+func NewInterpolatingValuesProvider(vp ValuesProvider) *InterpolatingValuesProvider {
+	if vp == nil {
+		vp = &NullValuesProvider{}
+	}
+
+	return &InterpolatingValuesProvider{base: vp}
+}
+
+func (ivp *InterpolatingValuesProvider) Read(name string) (val interface{}, found bool) {
+	val, found, _ = ivp.ReadWithError(name)
+	return
+}
+
+// ReadWithError same as Read but gives an error for broken or cyclic references
+func (ivp *InterpolatingValuesProvider) ReadWithError(name string) (val interface{}, found bool, err error) {
+	return ivp.resolve(name, []string{})
+}
+
+// resolve reads name and expands its references, stack contains names which are being resolved to detect cycles
+func (ivp *InterpolatingValuesProvider) resolve(name string, stack []string) (val interface{}, found bool, err error) {
+	val, found, err = ReadValue(ivp.base, name)
+	if err != nil || !found {
+		return
+	}
+
+	val, err = ivp.expandValue(val, append(stack, name))
+	if err != nil {
+		return nil, false, err
+	}
+
+	return val, true, nil
+}
+
+func (ivp *InterpolatingValuesProvider) expandValue(val interface{}, stack []string) (interface{}, error) {
+	return mapStringValues(val, func(input string) (string, error) {
+		return ExpandVariables(input, ivp.lookup(stack))
+	})
+}
+
+func (ivp *InterpolatingValuesProvider) lookup(stack []string) VariableLookup {
+	return func(name string) (string, bool, error) {
+		for i, resolvedName := range stack {
+			if resolvedName == name {
+				cycle := append(append([]string{}, stack[i:]...), name)
+				return "", false, fmt.Errorf("reference cycle %s", strings.Join(cycle, " -> "))
+			}
+		}
+
+		refs := ivp
+		if ivp.refs != nil {
+			refs = ivp.refs
+		}
+
+		val, found, err := refs.resolve(name, stack)
+		if err != nil || !found || val == nil {
+			return "", found, err
+		}
+
+		return fmt.Sprint(val), true, nil
+	}
+}
+
+// ToKeyValues gives values of the wrapped provider with expanded references, values with broken references are
+// given as they are and the errors are logged with io.OutputError
+func (ivp *InterpolatingValuesProvider) ToKeyValues() map[string]interface{} {
+	baseKvs := ivp.base.ToKeyValues()
+	res := make(map[string]interface{}, len(baseKvs))
+	for key, val := range baseKvs {
+		expandedVal, err := ivp.expandValue(val, []string{key})
+		if err != nil {
+			io2.OutputError(err, ReadLoggingTopic, "failed to expand option %s", key)
+			res[key] = val
+			continue
+		}
+		res[key] = expandedVal
+	}
+
+	return res
+}
+
+// Dump outputs values with expanded references as json, sensitive values are masked by CurrentRedactor
+func (ivp *InterpolatingValuesProvider) Dump(w io.Writer) (err error) {
+	data := CurrentRedactor().Redact(ivp.ToKeyValues())
+	jsonEncoder := json.NewEncoder(w)
+	return jsonEncoder.Encode(data)
+}
+
+// DumpFormatted outputs values in the given format, see DumpFormatted
+func (ivp *InterpolatingValuesProvider) DumpFormatted(w io.Writer, format DumpFormat) error {
+	return DumpFormatted(ivp, w, format)
+}
+
+func (ivp *InterpolatingValuesProvider) Label() string {
+	return "interpolated:" + ProviderLabel(ivp.base)
+}
+
+func (ivp *InterpolatingValuesProvider) DecoratedProvider() ValuesProvider {
+	return ivp.base
+}
+
+// Decorate wraps vp to expand references in its values, references are resolved against the wrapped provider of ivp,
+// so a leaf of a composite can reference options of other leaves
+func (ivp *InterpolatingValuesProvider) Decorate(vp ValuesProvider) ValuesProvider {
+	refs := ivp
+	if ivp.refs != nil {
+		refs = ivp.refs
+	}
+
+	return &InterpolatingValuesProvider{base: vp, refs: refs}
+}
+
+// KeyStyle gives the key style of the wrapped values provider
+func (ivp *InterpolatingValuesProvider) KeyStyle() KeyStyle {
+	return ProviderKeyStyle(ivp.base)
+}
+
+// HasStringValues tells if the wrapped values provider gives plain string values, see StringValuesProvider
+func (ivp *InterpolatingValuesProvider) HasStringValues() bool {
+	svp, ok := ivp.base.(StringValuesProvider)
+	return ok && svp.HasStringValues()
+}
@@ -0,0 +1,132 @@
+package options
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	io2 "github.com/breathbath/go_utils/v3/pkg/io"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandVariables(t *testing.T) {
+	vars := map[string]string{
+		"HOST":  "localhost",
+		"PORT":  "80",
+		"EMPTY": "",
+	}
+	lookup := func(name string) (string, bool, error) {
+		val, found := vars[name]
+		return val, found, nil
+	}
+
+	testCases := []struct {
+		input          string
+		expectedOutput string
+	}{
+		{input: "http://${HOST}:${PORT}/", expectedOutput: "http://localhost:80/"},
+		{input: "${MISSING}", expectedOutput: ""},
+		{input: "${MISSING:-default}", expectedOutput: "default"},
+		{input: "${EMPTY:-default}", expectedOutput: "default"},
+		{input: "${HOST:-default}", expectedOutput: "localhost"},
+		{input: "${MISSING:-${HOST}:${PORT}}", expectedOutput: "localhost:80"},
+		{input: "$${HOST} ${ HOST }", expectedOutput: "${HOST} localhost"},
+		{input: "$HOST costs 5$", expectedOutput: "$HOST costs 5$"},
+	}
+
+	for _, testCase := range testCases {
+		actualOutput, err := ExpandVariables(testCase.input, lookup)
+		assert.NoError(t, err, testCase.input)
+		assert.Equal(t, testCase.expectedOutput, actualOutput, testCase.input)
+	}
+}
+
+func TestExpandVariablesErrors(t *testing.T) {
+	lookup := func(name string) (string, bool, error) {
+		return "", false, errors.New("lookup failed for " + name)
+	}
+
+	_, err := ExpandVariables("${HOST}", lookup)
+	assert.EqualError(t, err, "lookup failed for HOST")
+
+	_, err = ExpandVariables("${HOST", lookup)
+	assert.EqualError(t, err, `unterminated variable reference in "${HOST"`)
+
+	_, err = ExpandVariables("${:-default}", lookup)
+	assert.EqualError(t, err, "empty variable name in ${:-default}")
+}
+
+func TestInterpolatingValuesProvider(t *testing.T) {
+	jsonProvider, err := NewJSONValuesProvider(strings.NewReader(`{
+		"db": {"user": "app", "host": "${DB_HOST:-localhost}", "port": 5432},
+		"dsn": "postgres://${db.user}@${db.host}:${db.port}/app",
+		"template": "$${db.user}",
+		"ref_template": "${template}",
+		"hosts": ["${db.host}", "backup"],
+		"urls": {"admin": "http://${db.host}/admin"}
+	}`))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	pb := New(NewInterpolatingValuesProvider(NewValuesProviderComposite(
+		NewMapValuesProvider(map[string]interface{}{"DB_HOST": "remote"}),
+		jsonProvider,
+	)))
+
+	dsn, err := pb.ReadRequiredString("dsn")
+	assert.NoError(t, err)
+	assert.Equal(t, "postgres://app@remote:5432/app", dsn)
+
+	assert.Equal(t, "${db.user}", pb.ReadString("template", ""))
+	assert.Equal(t, "${db.user}", pb.ReadString("ref_template", ""))
+	assert.Equal(t, []string{"remote", "backup"}, pb.ReadStrings("hosts"))
+	assert.Equal(t, "http://remote/admin", pb.ReadString("urls.admin", ""))
+	assert.Equal(t, 5432, pb.ReadInt("db.port", 0))
+
+	kvs := pb.BaseValuesProvider.ToKeyValues()
+	assert.Equal(t, "postgres://app@remote:5432/app", kvs["dsn"])
+	assert.Equal(t, "${db.user}", kvs["template"])
+	assert.Equal(t, map[string]interface{}{"user": "app", "host": "remote", "port": 5432}, kvs["db"])
+	assert.Equal(t, map[string]interface{}{"admin": "http://remote/admin"}, kvs["urls"])
+
+	assert.Equal(t, "localhost", New(NewInterpolatingValuesProvider(jsonProvider)).ReadString("db.host", ""))
+	assert.Equal(t, "interpolated:json", ProviderLabel(NewInterpolatingValuesProvider(jsonProvider)))
+}
+
+func TestInterpolatingValuesProviderCycles(t *testing.T) {
+	ivp := NewInterpolatingValuesProvider(NewMapValuesProvider(map[string]interface{}{
+		"a":      "${b}",
+		"b":      "x${c}",
+		"c":      "${a}",
+		"self":   "${self:-default}",
+		"broken": "${a",
+		"ok":     "fine",
+	}))
+	pb := New(ivp)
+
+	_, err := pb.ReadRequiredString("a")
+	assert.EqualError(t, err, "reference cycle a -> b -> c -> a")
+
+	_, err = pb.ReadRequiredString("c")
+	assert.EqualError(t, err, "reference cycle c -> a -> b -> c")
+
+	_, err = pb.ReadRequiredString("self")
+	assert.EqualError(t, err, "reference cycle self -> self")
+
+	_, err = pb.ReadRequiredString("broken")
+	assert.EqualError(t, err, `unterminated variable reference in "${a"`)
+
+	logger := &channelLoggerMock{messages: make(chan string, 10)}
+	io2.SetLogger(logger)
+	defer io2.SetLogger(io2.DefaultLogger{})
+
+	assert.Equal(t, "default", pb.ReadString("a", "default"))
+	assert.Contains(t, <-logger.messages, "reference cycle a -> b -> c -> a")
+
+	kvs := ivp.ToKeyValues()
+	assert.Equal(t, "${b}", kvs["a"])
+	assert.Equal(t, "fine", kvs["ok"])
+	assert.Len(t, logger.messages, 5)
+}
@@ -0,0 +1,206 @@
+package options
+
+import (
+	"strconv"
+	"strings"
+)
+
+// KeySeparator separates parts of a nested option name e.g. db.host or servers.0.port
+const KeySeparator = "."
+
+// KeysMode defines how nested values are represented in the ToKeyValues output
+type KeysMode int
+
+const (
+	// KeysModeTree keeps top level keys with nested objects as map[string]interface{} and lists as []interface{}
+	KeysModeTree KeysMode = iota
+	// KeysModeFlat converts nested objects to dotted keys e.g. {"db":{"host":"x"}} to {"db.host":"x"}
+	KeysModeFlat
+)
+
+// lookupNestedKey finds a value by a dotted name, the longest stored key which is a prefix of name wins,
+// the rest of the name is resolved with lookupPath e.g. servers.0.port for {"servers":[{"port":80}]}
+func lookupNestedKey(name string, load func(key interface{}) (interface{}, bool)) (val interface{}, found bool) {
+	if !strings.Contains(name, KeySeparator) {
+		return nil, false
+	}
+
+	parts := strings.Split(name, KeySeparator)
+	for i := len(parts) - 1; i > 0; i-- {
+		rootVal, rootFound := load(strings.Join(parts[:i], KeySeparator))
+		if !rootFound {
+			continue
+		}
+
+		val, found = lookupPath(rootVal, parts[i:])
+		if found {
+			return val, found
+		}
+	}
+
+	return nil, false
+}
+
+// lookupPath walks nested maps and lists by the path parts, list items are addressed by their index
+func lookupPath(val interface{}, path []string) (interface{}, bool) {
+	for _, part := range path {
+		switch typedVal := val.(type) {
+		case map[string]interface{}:
+			nextVal, ok := typedVal[part]
+			if !ok {
+				return nil, false
+			}
+			val = nextVal
+		case map[string]string:
+			nextVal, ok := typedVal[part]
+			if !ok {
+				return nil, false
+			}
+			val = nextVal
+		case []interface{}:
+			index, ok := parseListIndex(part, len(typedVal))
+			if !ok {
+				return nil, false
+			}
+			val = typedVal[index]
+		case []string:
+			index, ok := parseListIndex(part, len(typedVal))
+			if !ok {
+				return nil, false
+			}
+			val = typedVal[index]
+		default:
+			return nil, false
+		}
+	}
+
+	return val, true
+}
+
+func parseListIndex(part string, length int) (int, bool) {
+	index, err := strconv.Atoi(part)
+	if err != nil || index < 0 || index >= length {
+		return 0, false
+	}
+
+	return index, true
+}
+
+// FlattenKeyValues converts nested objects and lists of objects to dotted keys,
+// e.g. {"db":{"host":"x"},"servers":[{"port":80}]} to {"db.host":"x","servers.0.port":80},
+// lists of scalar values are kept as they are, so they can be read with ReadStrings
+func FlattenKeyValues(kvs map[string]interface{}) map[string]interface{} {
+	res := map[string]interface{}{}
+	for k, v := range kvs {
+		flattenValue(k, v, res)
+	}
+
+	return res
+}
+
+func flattenValue(key string, val interface{}, res map[string]interface{}) {
+	switch typedVal := val.(type) {
+	case map[string]interface{}:
+		if len(typedVal) == 0 {
+			res[key] = typedVal
+			return
+		}
+		for k, v := range typedVal {
+			flattenValue(key+KeySeparator+k, v, res)
+		}
+	case map[string]string:
+		if len(typedVal) == 0 {
+			res[key] = typedVal
+			return
+		}
+		for k, v := range typedVal {
+			res[key+KeySeparator+k] = v
+		}
+	case []interface{}:
+		if !hasNestedValues(typedVal) {
+			res[key] = typedVal
+			return
+		}
+		for i, v := range typedVal {
+			flattenValue(key+KeySeparator+strconv.Itoa(i), v, res)
+		}
+	default:
+		res[key] = val
+	}
+}
+
+// nestKeyValues converts dotted keys to nested objects, e.g. {"db.host":"x"} to {"db":{"host":"x"}},
+// a value of a key which is also a parent of other keys is replaced by the object of the nested keys
+func nestKeyValues(kvs map[string]interface{}) map[string]interface{} {
+	res := map[string]interface{}{}
+	for _, key := range sortedKeys(kvs) {
+		path := strings.Split(key, KeySeparator)
+		parent := res
+		for _, part := range path[:len(path)-1] {
+			child, isObject := parent[part].(map[string]interface{})
+			if !isObject {
+				child = map[string]interface{}{}
+				parent[part] = child
+			}
+			parent = child
+		}
+
+		lastPart := path[len(path)-1]
+		if _, isObject := parent[lastPart].(map[string]interface{}); !isObject {
+			parent[lastPart] = kvs[key]
+		}
+	}
+
+	return res
+}
+
+func hasNestedValues(items []interface{}) bool {
+	for _, item := range items {
+		switch item.(type) {
+		case map[string]interface{}, map[string]string, []interface{}:
+			return true
+		}
+	}
+
+	return false
+}
+
+// mapStringValues converts strings in val and in its nested maps and lists with mapper, other values are kept
+func mapStringValues(val interface{}, mapper func(input string) (string, error)) (interface{}, error) {
+	switch typedVal := val.(type) {
+	case string:
+		return mapper(typedVal)
+	case []string:
+		res := make([]string, 0, len(typedVal))
+		for _, item := range typedVal {
+			mappedItem, err := mapper(item)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, mappedItem)
+		}
+		return res, nil
+	case []interface{}:
+		res := make([]interface{}, 0, len(typedVal))
+		for _, item := range typedVal {
+			mappedItem, err := mapStringValues(item, mapper)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, mappedItem)
+		}
+		return res, nil
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(typedVal))
+		for key, item := range typedVal {
+			mappedItem, err := mapStringValues(item, mapper)
+			if err != nil {
+				return nil, err
+			}
+			res[key] = mappedItem
+		}
+		return res, nil
+	default:
+		return val, nil
+	}
+}
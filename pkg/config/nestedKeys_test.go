@@ -0,0 +1,176 @@
+package options
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const nestedJSONInput = `{
+	"db":{"host":"localhost","port":5432,"options":{"ssl":true}},
+	"servers":[{"port":80},{"port":8080,"hosts":["a.com","b.com"]}],
+	"tags":["one","two"],
+	"log.level":"debug"
+}`
+
+func TestJsonFileValuesProviderNestedRead(t *testing.T) {
+	jvp, err := NewJSONValuesProvider(strings.NewReader(nestedJSONInput))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	pb := New(jvp)
+
+	assert.Equal(t, "localhost", pb.ReadString("db.host", ""))
+	assert.Equal(t, 5432, pb.ReadInt("db.port", 0))
+	assert.Equal(t, true, pb.ReadBool("db.options.ssl", false))
+	assert.Equal(t, 80, pb.ReadInt("servers.0.port", 0))
+	assert.Equal(t, 8080, pb.ReadInt("servers.1.port", 0))
+	assert.Equal(t, "b.com", pb.ReadString("servers.1.hosts.1", ""))
+	assert.Equal(t, []string{"a.com", "b.com"}, pb.ReadStrings("servers.1.hosts"))
+	assert.Equal(t, []string{"one", "two"}, pb.ReadStrings("tags"))
+	assert.Equal(t, "two", pb.ReadString("tags.1", ""))
+	assert.Equal(t, "debug", pb.ReadString("log.level", ""))
+
+	_, found := jvp.Read("servers.2.port")
+	assert.False(t, found)
+
+	_, found = jvp.Read("servers.first.port")
+	assert.False(t, found)
+
+	_, found = jvp.Read("db.host.name")
+	assert.False(t, found)
+
+	_, found = jvp.Read("db.user")
+	assert.False(t, found)
+}
+
+func TestMapValuesProviderNestedRead(t *testing.T) {
+	mvp := NewMapValuesProvider(map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "localhost",
+		},
+		"labels":  map[string]string{"env": "prod"},
+		"hosts":   []string{"a.com", "b.com"},
+		"db.port": 5432,
+	})
+
+	val, found := mvp.Read("db.host")
+	assert.True(t, found)
+	assert.Equal(t, "localhost", val)
+
+	val, found = mvp.Read("labels.env")
+	assert.True(t, found)
+	assert.Equal(t, "prod", val)
+
+	val, found = mvp.Read("hosts.0")
+	assert.True(t, found)
+	assert.Equal(t, "a.com", val)
+
+	val, found = mvp.Read("db.port")
+	assert.True(t, found)
+	assert.Equal(t, 5432, val)
+
+	_, found = mvp.Read("hosts.2")
+	assert.False(t, found)
+}
+
+func TestJsonFileValuesProviderKeysMode(t *testing.T) {
+	jvp, err := NewJSONValuesProvider(strings.NewReader(nestedJSONInput))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	treeKvs := jvp.ToKeyValues()
+	assert.Equal(
+		t,
+		map[string]interface{}{"host": "localhost", "port": 5432, "options": map[string]interface{}{"ssl": true}},
+		treeKvs["db"],
+	)
+
+	jvp.SetKeysMode(KeysModeFlat)
+	flatKvs := jvp.ToKeyValues()
+	assert.Equal(
+		t,
+		map[string]interface{}{
+			"db.host":         "localhost",
+			"db.port":         5432,
+			"db.options.ssl":  true,
+			"servers.0.port":  80,
+			"servers.1.port":  8080,
+			"servers.1.hosts": []interface{}{"a.com", "b.com"},
+			"tags":            []interface{}{"one", "two"},
+			"log.level":       "debug",
+		},
+		flatKvs,
+	)
+
+	b := &bytes.Buffer{}
+	err = jvp.Dump(b)
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), `"db.host":"localhost"`)
+}
+
+func TestMapValuesProviderSetKeysModeConcurrently(t *testing.T) {
+	mvp := NewMapValuesProvider(map[string]interface{}{"db": map[string]interface{}{"host": "localhost"}})
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			mvp.SetKeysMode(KeysMode(i % 2))
+		}(i)
+		go func() {
+			defer wg.Done()
+			val, found := mvp.Read("db.host")
+			assert.True(t, found)
+			assert.Equal(t, "localhost", val)
+			assert.NotEmpty(t, mvp.ToKeyValues())
+		}()
+	}
+	wg.Wait()
+}
+
+func TestJSONValuesProviderRejectsTrailingData(t *testing.T) {
+	inputs := []string{
+		`{"host":"localhost"}{"host":"remote"}`,
+		`{"host":"localhost"}}`,
+		`{"host":"localhost"} garbage`,
+	}
+	for _, input := range inputs {
+		_, err := NewJSONValuesProvider(strings.NewReader(input))
+		assert.Error(t, err, input)
+	}
+
+	jvp, err := NewJSONValuesProvider(strings.NewReader("{\"host\":\"localhost\"}\n\t "))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+	assert.Equal(t, map[string]interface{}{"host": "localhost"}, jvp.ToKeyValues())
+}
+
+func TestFlattenKeyValues(t *testing.T) {
+	actualKvs := FlattenKeyValues(map[string]interface{}{
+		"empty":  map[string]interface{}{},
+		"labels": map[string]string{"env": "prod"},
+		"nested": []interface{}{[]interface{}{1, 2}},
+		"plain":  1,
+	})
+
+	assert.Equal(
+		t,
+		map[string]interface{}{
+			"empty":      map[string]interface{}{},
+			"labels.env": "prod",
+			"nested.0":   []interface{}{1, 2},
+			"plain":      1,
+		},
+		actualKvs,
+	)
+}
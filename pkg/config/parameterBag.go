@@ -1,6 +1,7 @@
 package options
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,16 +13,37 @@ import (
 
 	"github.com/breathbath/go_utils/v3/pkg/conv"
 	errs2 "github.com/breathbath/go_utils/v3/pkg/errs"
+	io2 "github.com/breathbath/go_utils/v3/pkg/io"
 )
 
+// ReadLoggingTopic is used as topic for outputs of failed option reads
+const ReadLoggingTopic = "config_read"
+
 type ValuesProvider interface {
 	Read(name string) (val interface{}, found bool)
 	Dump(w io.Writer) (err error)
 	ToKeyValues() map[string]interface{}
 }
 
+// ErrorAwareValuesProvider is implemented by values providers which can fail to read a value e.g. because of a broken file
+type ErrorAwareValuesProvider interface {
+	ReadWithError(name string) (val interface{}, found bool, err error)
+}
+
+// ReadValue reads name from vp and gives the read error if vp implements ErrorAwareValuesProvider
+func ReadValue(vp ValuesProvider, name string) (val interface{}, found bool, err error) {
+	if errorAwareProvider, ok := vp.(ErrorAwareValuesProvider); ok {
+		return errorAwareProvider.ReadWithError(name)
+	}
+
+	val, found = vp.Read(name)
+	return val, found, nil
+}
+
 type MapValuesProvider struct {
 	parameters *sync.Map
+	keysModeMx sync.RWMutex
+	keysMode   KeysMode
 }
 
 func NewMapValuesProvider(params map[string]interface{}) *MapValuesProvider {
@@ -51,24 +73,62 @@ func (mvp *MapValuesProvider) Copy(newParams map[string]interface{}) *MapValuesP
 
 	return &MapValuesProvider{
 		parameters: resultItems,
+		keysMode:   mvp.currentKeysMode(),
 	}
 }
 
+// SetKeysMode defines if ToKeyValues and Dump should give nested values as a tree or as flat dotted keys,
+// it's safe to call it concurrently with reading values
+func (mvp *MapValuesProvider) SetKeysMode(mode KeysMode) {
+	mvp.keysModeMx.Lock()
+	defer mvp.keysModeMx.Unlock()
+
+	mvp.keysMode = mode
+}
+
+func (mvp *MapValuesProvider) currentKeysMode() KeysMode {
+	mvp.keysModeMx.RLock()
+	defer mvp.keysModeMx.RUnlock()
+
+	return mvp.keysMode
+}
+
+// Read gives a value by name, dotted names like db.host or servers.0.port are resolved in nested values
 func (mvp *MapValuesProvider) Read(name string) (val interface{}, found bool) {
-	return mvp.parameters.Load(name)
+	val, found = mvp.parameters.Load(name)
+	if found {
+		return
+	}
+
+	return lookupNestedKey(name, mvp.parameters.Load)
+}
+
+func (mvp *MapValuesProvider) Label() string {
+	return "map"
 }
 
 func (mvp *MapValuesProvider) ToKeyValues() map[string]interface{} {
-	return conv.ConvertSyncMapToMap(mvp.parameters)
+	kvs := conv.ConvertSyncMapToMap(mvp.parameters)
+	if mvp.currentKeysMode() == KeysModeFlat {
+		return FlattenKeyValues(kvs)
+	}
+
+	return kvs
 }
 
+// Dump outputs values as json, sensitive values are masked by CurrentRedactor
 func (mvp *MapValuesProvider) Dump(w io.Writer) (err error) {
-	data := conv.ConvertSyncMapToMap(mvp.parameters)
+	data := CurrentRedactor().Redact(mvp.ToKeyValues())
 	jsonEncoder := json.NewEncoder(w)
 	err = jsonEncoder.Encode(data)
 	return
 }
 
+// DumpFormatted outputs values in the given format, see DumpFormatted
+func (mvp *MapValuesProvider) DumpFormatted(w io.Writer, format DumpFormat) error {
+	return DumpFormatted(mvp, w, format)
+}
+
 type NullValuesProvider struct{}
 
 func (nvp *NullValuesProvider) Read(name string) (val interface{}, found bool) {
@@ -79,6 +139,15 @@ func (nvp *NullValuesProvider) Dump(w io.Writer) (err error) {
 	return
 }
 
+// DumpFormatted outputs values in the given format, see DumpFormatted
+func (nvp *NullValuesProvider) DumpFormatted(w io.Writer, format DumpFormat) error {
+	return DumpFormatted(nvp, w, format)
+}
+
+func (nvp *NullValuesProvider) Label() string {
+	return "null"
+}
+
 func (nvp *NullValuesProvider) ToKeyValues() map[string]interface{} {
 	return map[string]interface{}{}
 }
@@ -89,13 +158,31 @@ func (evp *EnvValuesProvider) Read(name string) (val interface{}, found bool) {
 	return os.LookupEnv(name)
 }
 
+// Dump outputs env variables as json list of KEY=value items, sensitive values are masked by CurrentRedactor
 func (evp *EnvValuesProvider) Dump(w io.Writer) (err error) {
-	data := os.Environ()
+	data := CurrentRedactor().RedactEnvironment(os.Environ())
 	jsonEncoder := json.NewEncoder(w)
 	err = jsonEncoder.Encode(data)
 	return
 }
 
+// DumpFormatted outputs values in the given format, see DumpFormatted
+func (evp *EnvValuesProvider) DumpFormatted(w io.Writer, format DumpFormat) error {
+	return DumpFormatted(evp, w, format)
+}
+
+func (evp *EnvValuesProvider) Label() string {
+	return "env"
+}
+
+func (evp *EnvValuesProvider) KeyStyle() KeyStyle {
+	return KeyStyleEnv
+}
+
+func (evp *EnvValuesProvider) HasStringValues() bool {
+	return true
+}
+
 func (evp *EnvValuesProvider) ToKeyValues() map[string]interface{} {
 	res := map[string]interface{}{}
 	for _, env := range os.Environ() {
@@ -117,45 +204,73 @@ func NewJSONValuesProvider(jsond io.Reader) (jfvp *JSONFileValuesProvider, err e
 		return
 	}
 
-	var objmap map[string]json.RawMessage
-	err = json.Unmarshal(data, &objmap)
+	objmap, err := parseJSONValues(data)
 	if err != nil {
 		return
 	}
 
-	params := &sync.Map{}
+	return &JSONFileValuesProvider{
+		vals: MapValuesProvider{
+			parameters: conv.ConvertMapToSyncMap(objmap),
+		},
+	}, nil
+}
+
+// parseJSONValues decodes a json object keeping integer numbers as int and other numbers as float64,
+// nested objects are given as map[string]interface{} and lists as []interface{}
+func parseJSONValues(data []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var objmap map[string]interface{}
+	err := decoder.Decode(&objmap)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid json: unexpected data after the top-level value at offset %d", decoder.InputOffset())
+	}
+
 	for k, val := range objmap {
-		valStr := string(val)
-		if strings.HasPrefix(valStr, `"`) || valStr == "" {
-			params.Store(k, strings.Trim(string(val), `"`))
-			continue
-		}
+		objmap[k] = convertJSONNumbers(val)
+	}
 
-		if valStr == "null" {
-			params.Store(k, nil)
-			continue
-		}
+	return objmap, nil
+}
 
-		intVal, err := strconv.Atoi(valStr)
+func convertJSONNumbers(val interface{}) interface{} {
+	switch typedVal := val.(type) {
+	case json.Number:
+		intVal, err := strconv.Atoi(typedVal.String())
 		if err == nil {
-			params.Store(k, intVal)
-			continue
+			return intVal
 		}
 
-		floatVal, err := strconv.ParseFloat(valStr, 64)
+		floatVal, err := typedVal.Float64()
 		if err == nil {
-			params.Store(k, floatVal)
-			continue
+			return floatVal
 		}
 
-		params.Store(k, valStr)
-	}
-
-	vals := MapValuesProvider{
-		parameters: params,
+		return typedVal.String()
+	case map[string]interface{}:
+		for k, v := range typedVal {
+			typedVal[k] = convertJSONNumbers(v)
+		}
+		return typedVal
+	case []interface{}:
+		for i, v := range typedVal {
+			typedVal[i] = convertJSONNumbers(v)
+		}
+		return typedVal
+	default:
+		return val
 	}
+}
 
-	return &JSONFileValuesProvider{vals: vals}, nil
+// SetKeysMode defines if ToKeyValues and Dump should give nested values as a tree or as flat dotted keys
+func (jfvp *JSONFileValuesProvider) SetKeysMode(mode KeysMode) {
+	jfvp.vals.SetKeysMode(mode)
 }
 
 func (jfvp *JSONFileValuesProvider) Read(name string) (val interface{}, found bool) {
@@ -166,50 +281,23 @@ func (jfvp *JSONFileValuesProvider) Dump(w io.Writer) (err error) {
 	return jfvp.vals.Dump(w)
 }
 
-func (jfvp *JSONFileValuesProvider) ToKeyValues() map[string]interface{} {
-	return jfvp.vals.ToKeyValues()
-}
-
-type ValuesProviderComposite struct {
-	providers []ValuesProvider
+// DumpFormatted outputs values in the given format, see DumpFormatted
+func (jfvp *JSONFileValuesProvider) DumpFormatted(w io.Writer, format DumpFormat) error {
+	return DumpFormatted(jfvp, w, format)
 }
 
-func NewValuesProviderComposite(vps ...ValuesProvider) *ValuesProviderComposite {
-	return &ValuesProviderComposite{providers: vps}
+func (jfvp *JSONFileValuesProvider) Label() string {
+	return "json"
 }
 
-func (vpc *ValuesProviderComposite) Read(name string) (val interface{}, found bool) {
-	for _, vp := range vpc.providers {
-		val, found = vp.Read(name)
-		if found {
-			return
-		}
-	}
-
-	return
-}
-
-func (vpc *ValuesProviderComposite) ToKeyValues() map[string]interface{} {
-	res := map[string]interface{}{}
-	for _, vp := range vpc.providers {
-		kvs := vp.ToKeyValues()
-		for k, v := range kvs {
-			res[k] = v
-		}
-	}
-
-	return res
-}
-
-func (vpc *ValuesProviderComposite) Dump(w io.Writer) (err error) {
-	kvs := vpc.ToKeyValues()
-	jsonEncoder := json.NewEncoder(w)
-	return jsonEncoder.Encode(kvs)
+func (jfvp *JSONFileValuesProvider) ToKeyValues() map[string]interface{} {
+	return jfvp.vals.ToKeyValues()
 }
 
 // ParameterBag construction for holding configuration options
 type ParameterBag struct {
 	BaseValuesProvider ValuesProvider
+	usage              *UsageRegistry
 }
 
 // New creates empty bag
@@ -223,12 +311,29 @@ func New(vp ValuesProvider) *ParameterBag {
 	}
 }
 
-// Read reads interface value, if not found, will read from envs, if not found there will return defaultVal
-func (p *ParameterBag) Read(name string, defaultVal interface{}) (interface{}, bool) {
+func (p *ParameterBag) readValue(name string) (val interface{}, found bool, err error) {
 	if p.BaseValuesProvider == nil {
 		p.BaseValuesProvider = &NullValuesProvider{}
 	}
-	val, found := p.BaseValuesProvider.Read(name)
+
+	return ReadValue(p.BaseValuesProvider, name)
+}
+
+// Read reads interface value, if not found, will read from envs, if not found there will return defaultVal,
+// read errors of providers are logged with io.OutputError and defaultVal is returned
+func (p *ParameterBag) Read(name string, defaultVal interface{}) (interface{}, bool) {
+	return p.read(name, "value", defaultVal)
+}
+
+// read same as Read but records the usage of the option with the type of the calling reader
+func (p *ParameterBag) read(name, readerType string, defaultVal interface{}) (interface{}, bool) {
+	val, found, err := p.readValue(name)
+	p.recordUsage(name, readerType, defaultVal, false, found && err == nil)
+	if err != nil {
+		io2.OutputError(err, ReadLoggingTopic, "failed to read option %s", name)
+		return defaultVal, false
+	}
+
 	if found {
 		return val, found
 	}
@@ -236,9 +341,19 @@ func (p *ParameterBag) Read(name string, defaultVal interface{}) (interface{}, b
 	return defaultVal, found
 }
 
-// ReadRequired reads interface value, if not found, will return error
+// ReadRequired reads interface value, if not found or failed to read, will return error
 func (p *ParameterBag) ReadRequired(name string) (interface{}, error) {
-	valI, found := p.Read(name, nil)
+	return p.readRequired(name, "value")
+}
+
+// readRequired same as ReadRequired but records the usage of the option with the type of the calling reader
+func (p *ParameterBag) readRequired(name, readerType string) (interface{}, error) {
+	valI, found, err := p.readValue(name)
+	p.recordUsage(name, readerType, nil, true, found && err == nil)
+	if err != nil {
+		return nil, err
+	}
+
 	if !found {
 		return valI, fmt.Errorf("required option %s is empty", name)
 	}
@@ -248,7 +363,7 @@ func (p *ParameterBag) ReadRequired(name string) (interface{}, error) {
 
 // ReadString same as Read but returns a string
 func (p *ParameterBag) ReadString(name, defaultVal string) string {
-	valI, found := p.Read(name, defaultVal)
+	valI, found := p.read(name, "string", defaultVal)
 	if !found {
 		return defaultVal
 	}
@@ -263,7 +378,7 @@ func (p *ParameterBag) ReadString(name, defaultVal string) string {
 
 // ReadRequiredString same as ReadRequired but returns string or error
 func (p *ParameterBag) ReadRequiredString(name string) (string, error) {
-	valI, err := p.ReadRequired(name)
+	valI, err := p.readRequired(name, "string")
 	if err != nil {
 		return "", err
 	}
@@ -279,48 +394,129 @@ func (p *ParameterBag) ReadRequiredString(name string) (string, error) {
 	return val, nil
 }
 
-// ReadStrings same as Read but returns []string
+// ReadStrings same as Read but returns []string, string values of env variables and flags are split by commas
+// e.g. "a.com, b.com", see StringValuesProvider, an empty string gives defaultVal
 func (p *ParameterBag) ReadStrings(name string, defaultVal ...string) []string {
-	valI, found := p.Read(name, defaultVal)
+	valI, found := p.read(name, "strings", defaultVal)
 	if !found {
 		return defaultVal
 	}
 
-	val, ok := valI.([]string)
-	if !ok {
-		valStr, okStr := valI.(string)
-		if okStr {
-			return []string{valStr}
-		}
+	val, ok := p.convertToList(name, valI)
+	if !ok || isEmptyString(valI) {
 		return defaultVal
 	}
 
 	return val
 }
 
-// ReadRequiredStrings same as Read but returns []string
+// ReadRequiredStrings same as ReadStrings but fails if value is missing, an empty string gives an empty list
 func (p *ParameterBag) ReadRequiredStrings(name string) ([]string, error) {
-	valI, err := p.ReadRequired(name)
+	valI, err := p.readRequired(name, "strings")
 	if err != nil {
 		return []string{}, err
 	}
 
-	val, ok := valI.([]string)
+	val, ok := p.convertToList(name, valI)
 	if !ok {
-		valStr, okStr := valI.(string)
-		if okStr {
-			return []string{valStr}, nil
-		}
-
 		return []string{}, fmt.Errorf("cannot convert value %v to []string", valI)
 	}
 
 	return val, nil
 }
 
+func isEmptyString(valI interface{}) bool {
+	valStr, ok := valI.(string)
+	return ok && strings.TrimSpace(valStr) == ""
+}
+
+// StringValuesProvider is implemented by values providers which give all values as plain strings e.g. env variables
+// and command line flags, list readers split string values of such providers by commas
+type StringValuesProvider interface {
+	HasStringValues() bool
+}
+
+// isStringSource tells if the value of name is given by a StringValuesProvider, composites, decorated composites
+// and sub bags are resolved to the leaf provider which gives the value
+func isStringSource(vp ValuesProvider, name string) bool {
+	_, isPrefixed := vp.(*PrefixedValuesProvider)
+	if !isPrefixed && !combinesProviders(vp) {
+		svp, ok := vp.(StringValuesProvider)
+		return ok && svp.HasStringValues()
+	}
+
+	leaves, translateKey := sourceLeaves(vp)
+	for _, leafProvider := range leaves {
+		key := translateKey(leafProvider, name)
+		if _, found := leafProvider.Read(key); found {
+			return isStringSource(leafProvider, key)
+		}
+	}
+
+	return false
+}
+
+// convertToList same as convertToStrings but splits string values of StringValuesProvider by commas,
+// an empty string gives an empty list
+func (p *ParameterBag) convertToList(name string, valI interface{}) ([]string, bool) {
+	valStr, ok := valI.(string)
+	if !ok {
+		return convertToStrings(valI)
+	}
+
+	if strings.TrimSpace(valStr) == "" {
+		return []string{}, true
+	}
+
+	if !isStringSource(p.BaseValuesProvider, name) {
+		return []string{valStr}, true
+	}
+
+	return splitList(valStr), true
+}
+
+// splitList splits a comma separated list and trims spaces of the items, an empty string gives an empty list
+func splitList(valStr string) []string {
+	if strings.TrimSpace(valStr) == "" {
+		return []string{}
+	}
+
+	items := strings.Split(valStr, ",")
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+
+	return items
+}
+
+// convertToStrings accepts []string, a single string or a list of scalars as it comes from json or yaml
+func convertToStrings(valI interface{}) ([]string, bool) {
+	switch typedVal := valI.(type) {
+	case []string:
+		return typedVal, true
+	case string:
+		return []string{typedVal}, true
+	case []interface{}:
+		res := make([]string, 0, len(typedVal))
+		for _, item := range typedVal {
+			switch item.(type) {
+			case map[string]interface{}, []interface{}:
+				return nil, false
+			case nil:
+				res = append(res, "")
+			default:
+				res = append(res, fmt.Sprint(item))
+			}
+		}
+		return res, true
+	default:
+		return nil, false
+	}
+}
+
 // ReadInt same as Read but returns a int
 func (p *ParameterBag) ReadInt(name string, defaultVal int) int {
-	valI, found := p.Read(name, defaultVal)
+	valI, found := p.read(name, "int", defaultVal)
 	if !found {
 		return defaultVal
 	}
@@ -339,7 +535,7 @@ func (p *ParameterBag) ReadInt(name string, defaultVal int) int {
 
 // ReadRequiredInt same as Read but returns a int and fails if value is missing
 func (p *ParameterBag) ReadRequiredInt(name string) (int, error) {
-	valI, err := p.ReadRequired(name)
+	valI, err := p.readRequired(name, "int")
 	if err != nil {
 		return 0, err
 	}
@@ -362,7 +558,7 @@ func (p *ParameterBag) ReadRequiredInt(name string) (int, error) {
 
 // ReadInt same as Read but returns a int
 func (p *ParameterBag) ReadInt64(name string, defaultVal int64) int64 {
-	valI, found := p.Read(name, defaultVal)
+	valI, found := p.read(name, "int64", defaultVal)
 	if !found {
 		return defaultVal
 	}
@@ -381,7 +577,7 @@ func (p *ParameterBag) ReadInt64(name string, defaultVal int64) int64 {
 
 // ReadRequiredInt same as Read but returns a int64 and fails if value is missing
 func (p *ParameterBag) ReadRequiredInt64(name string) (int64, error) {
-	valI, err := p.ReadRequired(name)
+	valI, err := p.readRequired(name, "int64")
 	if err != nil {
 		return 0, err
 	}
@@ -398,43 +594,65 @@ func (p *ParameterBag) ReadRequiredInt64(name string) (int64, error) {
 	return val, nil
 }
 
-// ReadDuration reads int value and converts it to duration identified by the unit, if not set, will return defaultVal
+// ReadDuration reads int value and converts it to duration identified by the unit or a duration string e.g. "1m30s",
+// if not set, will return defaultVal
 func (p *ParameterBag) ReadDuration(name string, unit time.Duration, defaultVal uint) time.Duration {
-	val, err := p.ReadRequiredDuration(name, unit)
+	defaultDur := unit * time.Duration(defaultVal)
+	valI, found := p.read(name, "duration", defaultDur)
+	if !found {
+		return defaultDur
+	}
+
+	val, err := convertToDuration(valI, unit)
 	if err != nil {
-		return unit * time.Duration(defaultVal)
+		return defaultDur
 	}
 
 	return val
 }
 
-// ReadRequiredDuration reads int value and converts it to duration identified by the unit, if not set, will return error
+// ReadRequiredDuration reads int value and converts it to duration identified by the unit or a duration string
+// as in time.ParseDuration, if not set, will return error
 func (p *ParameterBag) ReadRequiredDuration(name string, unit time.Duration) (time.Duration, error) {
-	valI, err := p.ReadRequired(name)
+	valI, err := p.readRequired(name, "duration")
 	if err != nil {
 		return 0, err
 	}
 
+	return convertToDuration(valI, unit)
+}
+
+func convertToDuration(valI interface{}, unit time.Duration) (time.Duration, error) {
 	val, ok := valI.(time.Duration)
 	if ok {
 		return val, nil
 	}
 
-	valUint, err := p.ReadRequiredUint(name)
+	valStr := strings.TrimSpace(fmt.Sprint(valI))
+	valUint, err := strconv.ParseUint(valStr, 10, 32)
+	if err == nil {
+		return unit * time.Duration(valUint), nil
+	}
+
+	val, err = time.ParseDuration(valStr)
 	if err != nil {
-		return 0, err
+		return 0, fmt.Errorf("cannot convert %v to duration", valI)
 	}
 
-	return unit * time.Duration(valUint), nil
+	return val, nil
 }
 
 // ReadBool same as Read but returns a bool or defaultVal
 func (p *ParameterBag) ReadBool(name string, defaultVal bool) bool {
-	valI, found := p.Read(name, defaultVal)
+	valI, found := p.read(name, "bool", defaultVal)
 	if !found {
 		return defaultVal
 	}
 
+	return convertToBool(valI)
+}
+
+func convertToBool(valI interface{}) bool {
 	val, ok := valI.(bool)
 	if !ok {
 		valStr := strings.TrimSpace(fmt.Sprint(valI))
@@ -456,22 +674,17 @@ func (p *ParameterBag) ReadBool(name string, defaultVal bool) bool {
 
 // ReadRequiredBool same as ReadRequired but returns bool or error
 func (p *ParameterBag) ReadRequiredBool(name string) (bool, error) {
-	valI, err := p.ReadRequired(name)
+	valI, err := p.readRequired(name, "bool")
 	if err != nil {
 		return false, err
 	}
 
-	val, ok := valI.(bool)
-	if !ok {
-		return p.ReadBool(name, false), nil
-	}
-
-	return val, nil
+	return convertToBool(valI), nil
 }
 
 // ReadUint same as Read but returns a uint
 func (p *ParameterBag) ReadUint(name string, defaultVal uint) uint {
-	valI, found := p.Read(name, defaultVal)
+	valI, found := p.read(name, "uint", defaultVal)
 	if !found {
 		return defaultVal
 	}
@@ -490,7 +703,7 @@ func (p *ParameterBag) ReadUint(name string, defaultVal uint) uint {
 
 // ReadRequiredUint same as ReadRequired but returns uint or error
 func (p *ParameterBag) ReadRequiredUint(name string) (uint, error) {
-	valI, err := p.ReadRequired(name)
+	valI, err := p.readRequired(name, "uint")
 	if err != nil {
 		return 0, err
 	}
@@ -520,7 +733,35 @@ func (p *ParameterBag) CheckRequiredValues(keys []string) error {
 	return errs.Result(" ")
 }
 
+// ToKeyValues gives all values with masked sensitive values, use BaseValuesProvider.ToKeyValues to get the raw values
+func (p *ParameterBag) ToKeyValues() map[string]interface{} {
+	if p.BaseValuesProvider == nil {
+		p.BaseValuesProvider = &NullValuesProvider{}
+	}
+
+	return CurrentRedactor().Redact(p.BaseValuesProvider.ToKeyValues())
+}
+
+// Dump outputs all values as json, sensitive values are masked by CurrentRedactor
+func (p *ParameterBag) Dump(w io.Writer) error {
+	jsonEncoder := json.NewEncoder(w)
+	return jsonEncoder.Encode(p.ToKeyValues())
+}
+
+/*
+MergeParameterBag combines values of both bags, values of p take precedence over values of m,
+the bag gets a new ValuesProviderComposite with layers of p followed by layers of m, so composites of both bags
+are not changed
+*/
 func (p *ParameterBag) MergeParameterBag(m *ParameterBag) {
-	valuesProviderComposite := NewValuesProviderComposite(p.BaseValuesProvider, m.BaseValuesProvider)
-	p.BaseValuesProvider = valuesProviderComposite
+	if p.BaseValuesProvider == nil {
+		p.BaseValuesProvider = &NullValuesProvider{}
+	}
+
+	layers := providerLayers(p.BaseValuesProvider)
+	if m.BaseValuesProvider != nil {
+		layers = append(layers, providerLayers(m.BaseValuesProvider)...)
+	}
+
+	p.BaseValuesProvider = NewLayeredValuesProviderComposite(layers...)
 }
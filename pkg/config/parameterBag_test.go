@@ -489,10 +489,10 @@ func TestParameterBagRead(t *testing.T) {
 	assert.Equal(t, time.Minute, val31)
 
 	_, err = pb.ReadRequiredDuration("strval", time.Minute)
-	assert.EqualError(t, err, "cannot convert someStr to uint")
+	assert.EqualError(t, err, "cannot convert someStr to duration")
 
 	_, err = pb.ReadRequiredDuration("struct", time.Minute)
-	assert.EqualError(t, err, "cannot convert {true} to uint")
+	assert.EqualError(t, err, "cannot convert {true} to duration")
 
 	val32 := pb.ReadBool("boolStrFalse", true)
 	assert.Equal(t, false, val32)
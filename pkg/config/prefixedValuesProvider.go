@@ -0,0 +1,215 @@
+package options
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// KeyStyle defines how option names are formed in a values provider
+type KeyStyle int
+
+const (
+	// KeyStyleDotted is used by file providers e.g. db.host
+	KeyStyleDotted KeyStyle = iota
+	// KeyStyleEnv is used by env variables e.g. APP_DB_HOST
+	KeyStyleEnv
+)
+
+// KeyStyler is implemented by values providers which don't use dotted option names
+type KeyStyler interface {
+	KeyStyle() KeyStyle
+}
+
+// ProviderKeyStyle gives the key style of vp or KeyStyleDotted if vp doesn't implement KeyStyler
+func ProviderKeyStyle(vp ValuesProvider) KeyStyle {
+	if styler, ok := vp.(KeyStyler); ok {
+		return styler.KeyStyle()
+	}
+
+	return KeyStyleDotted
+}
+
+// SubOptions defines how names of a prefixed sub bag are translated to the names of the underlying providers
+type SubOptions struct {
+	// Separator joins the prefix and the name for dotted providers, KeySeparator is used if empty
+	Separator string
+	// EnvPrefix is prepended to names of env style providers e.g. APP gives APP_DB_HOST for the db prefix and host name
+	EnvPrefix string
+	// EnvSeparator joins the env prefix, the prefix and the name and replaces ., - and Separator in them,
+	// _ is used if empty
+	EnvSeparator string
+	// EnvLowerCase keeps the case of env style names, otherwise they are converted to upper case
+	EnvLowerCase bool
+}
+
+func (so SubOptions) dottedKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+
+	sep := so.Separator
+	if sep == "" {
+		sep = KeySeparator
+	}
+
+	return prefix + sep + name
+}
+
+// envKey joins non empty parts of the env prefix, prefix and name, an empty name gives the common prefix of all names
+func (so SubOptions) envKey(prefix, name string) string {
+	sep := so.EnvSeparator
+	if sep == "" {
+		sep = "_"
+	}
+
+	parts := []string{}
+	for _, part := range []string{so.EnvPrefix, prefix} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	parts = append(parts, name)
+
+	replacements := []string{".", sep, "-", sep}
+	if so.Separator != "" {
+		replacements = append(replacements, so.Separator, sep)
+	}
+	key := strings.NewReplacer(replacements...).Replace(strings.Join(parts, sep))
+
+	if so.EnvLowerCase {
+		return key
+	}
+
+	return strings.ToUpper(key)
+}
+
+// PrefixedValuesProvider gives values of a prefix namespace of the underlying providers,
+// e.g. host is read as db.host from file providers and as APP_DB_HOST from env providers
+type PrefixedValuesProvider struct {
+	base   ValuesProvider
+	prefix string
+	opts   SubOptions
+}
+
+// NewPrefixedValuesProvider creates a provider which reads values of the prefix namespace from vp
+func NewPrefixedValuesProvider(vp ValuesProvider, prefix string, opts SubOptions) *PrefixedValuesProvider {
+	if vp == nil {
+		vp = &NullValuesProvider{}
+	}
+
+	return &PrefixedValuesProvider{
+		base:   vp,
+		prefix: prefix,
+		opts:   opts,
+	}
+}
+
+func (pvp *PrefixedValuesProvider) translateKey(vp ValuesProvider, name string) string {
+	if ProviderKeyStyle(vp) == KeyStyleEnv {
+		return pvp.opts.envKey(pvp.prefix, name)
+	}
+
+	return pvp.opts.dottedKey(pvp.prefix, name)
+}
+
+// Read reads the translated name from the combined providers in their precedence order
+func (pvp *PrefixedValuesProvider) Read(name string) (val interface{}, found bool) {
+	val, found, _ = pvp.ReadWithError(name)
+	return
+}
+
+// ReadWithError same as Read but gives the first read error of the combined providers
+func (pvp *PrefixedValuesProvider) ReadWithError(name string) (val interface{}, found bool, err error) {
+	for _, leafProvider := range leafProviders(pvp.base) {
+		val, found, err = ReadValue(leafProvider, pvp.translateKey(leafProvider, name))
+		if err != nil {
+			return nil, false, err
+		}
+		if found {
+			return
+		}
+	}
+
+	return nil, false, nil
+}
+
+// ToKeyValues gives flat values of the prefix namespace with the prefix stripped, names from env style providers
+// are converted to lower case e.g. APP_DB_MAX_CONNS is given as max_conns
+func (pvp *PrefixedValuesProvider) ToKeyValues() map[string]interface{} {
+	res := map[string]interface{}{}
+	leaves := leafProviders(pvp.base)
+	for i := len(leaves) - 1; i >= 0; i-- {
+		for name, val := range pvp.leafKeyValues(leaves[i]) {
+			res[name] = val
+		}
+	}
+
+	return res
+}
+
+// leafKeyValues gives flat values of the prefix namespace of a leaf provider with the prefix stripped
+func (pvp *PrefixedValuesProvider) leafKeyValues(leafProvider ValuesProvider) map[string]interface{} {
+	res := map[string]interface{}{}
+	keyPrefix := pvp.translateKey(leafProvider, "")
+	kvs := leafProvider.ToKeyValues()
+	isEnvStyle := ProviderKeyStyle(leafProvider) == KeyStyleEnv
+	if !isEnvStyle {
+		kvs = FlattenKeyValues(kvs)
+	}
+
+	for key, val := range kvs {
+		if !strings.HasPrefix(key, keyPrefix) || len(key) == len(keyPrefix) {
+			continue
+		}
+
+		name := strings.TrimPrefix(key, keyPrefix)
+		if isEnvStyle && !pvp.opts.EnvLowerCase {
+			name = strings.ToLower(name)
+		}
+		res[name] = val
+	}
+
+	return res
+}
+
+// Dump outputs values of the prefix namespace as json, sensitive values are masked by CurrentRedactor
+func (pvp *PrefixedValuesProvider) Dump(w io.Writer) (err error) {
+	data := CurrentRedactor().Redact(pvp.ToKeyValues())
+	jsonEncoder := json.NewEncoder(w)
+	return jsonEncoder.Encode(data)
+}
+
+// DumpFormatted outputs values in the given format, see DumpFormatted
+func (pvp *PrefixedValuesProvider) DumpFormatted(w io.Writer, format DumpFormat) error {
+	return DumpFormatted(pvp, w, format)
+}
+
+func (pvp *PrefixedValuesProvider) Label() string {
+	return "sub:" + pvp.prefix
+}
+
+// Sub gives a bag which reads values of the prefix namespace, e.g. Sub("db").ReadString("host", "") reads db.host
+// from file providers and DB_HOST from env providers, a sub bag of a sub bag keeps the options of its parent
+func (p *ParameterBag) Sub(prefix string) *ParameterBag {
+	opts := SubOptions{}
+	if parent, ok := p.BaseValuesProvider.(*PrefixedValuesProvider); ok {
+		opts = parent.opts
+	}
+
+	return p.SubWithOptions(prefix, opts)
+}
+
+// SubWithOptions same as Sub but with custom name translation rules, e.g. SubOptions{EnvPrefix: "APP"} gives APP_DB_HOST
+// nested sub bags combine their prefixes e.g. Sub("db").Sub("replica") reads db.replica.host and DB_REPLICA_HOST
+func (p *ParameterBag) SubWithOptions(prefix string, opts SubOptions) *ParameterBag {
+	var sub *ParameterBag
+	if parent, ok := p.BaseValuesProvider.(*PrefixedValuesProvider); ok {
+		sub = New(NewPrefixedValuesProvider(parent.base, parent.opts.dottedKey(parent.prefix, prefix), opts))
+	} else {
+		sub = New(NewPrefixedValuesProvider(p.BaseValuesProvider, prefix, opts))
+	}
+	sub.usage = p.usage
+
+	return sub
+}
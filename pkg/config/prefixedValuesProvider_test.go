@@ -0,0 +1,206 @@
+package options
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParameterBagSub(t *testing.T) {
+	envs := map[string]string{
+		"APP_DB_HOST":         "envHost",
+		"APP_DB_MAX_CONNS":    "10",
+		"APP_DB_REPLICA_PORT": "5433",
+		"APP_CACHE_HOST":      "cacheHost",
+	}
+	for key, val := range envs {
+		err := os.Setenv(key, val)
+		assert.NoError(t, err)
+	}
+	defer func() {
+		for key := range envs {
+			err := os.Unsetenv(key)
+			if err != nil {
+				log.Println(err.Error())
+			}
+		}
+	}()
+
+	jsonProvider, err := NewJSONValuesProvider(strings.NewReader(
+		`{"db":{"host":"jsonHost","port":5432,"user":"admin","replica":{"host":"replicaHost"}},"cache":{"host":"jsonCache"}}`,
+	))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	pb := New(NewValuesProviderComposite(&EnvValuesProvider{}, jsonProvider))
+	dbBag := pb.SubWithOptions("db", SubOptions{EnvPrefix: "APP"})
+
+	assert.Equal(t, "envHost", dbBag.ReadString("host", ""))
+	assert.Equal(t, 5432, dbBag.ReadInt("port", 0))
+	assert.Equal(t, 10, dbBag.ReadInt("max_conns", 0))
+	assert.Equal(t, "admin", dbBag.ReadString("user", ""))
+	assert.Equal(t, "default", dbBag.ReadString("password", "default"))
+
+	replicaBag := dbBag.Sub("replica")
+	assert.Equal(t, "replicaHost", replicaBag.ReadString("host", ""))
+	assert.Equal(t, 5433, replicaBag.ReadInt("port", 0))
+
+	replicaBag = dbBag.SubWithOptions("replica", SubOptions{})
+	assert.Equal(t, 0, replicaBag.ReadInt("port", 0))
+
+	assert.Equal(
+		t,
+		map[string]interface{}{
+			"host":         "envHost",
+			"port":         5432,
+			"user":         "admin",
+			"max_conns":    "10",
+			"replica.host": "replicaHost",
+			"replica_port": "5433",
+		},
+		dbBag.BaseValuesProvider.ToKeyValues(),
+	)
+
+	assert.Equal(t, "jsonCache", New(jsonProvider).Sub("cache").ReadString("host", ""))
+	assert.Equal(t, "sub:db", ProviderLabel(dbBag.BaseValuesProvider))
+}
+
+func TestParameterBagSubWithCustomOptions(t *testing.T) {
+	dotEnvProvider, err := NewDotEnvValuesProvider(strings.NewReader("svc__db__max__conns=20\nsvc__db__password=secret"))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	mapProvider := NewMapValuesProvider(map[string]interface{}{
+		"db/host": "mapHost",
+		"db.host": "dottedHost",
+	})
+
+	pb := New(NewValuesProviderComposite(NewLabeledValuesProvider("local", dotEnvProvider), mapProvider))
+	dbBag := pb.SubWithOptions("db", SubOptions{
+		Separator:    "/",
+		EnvPrefix:    "svc",
+		EnvSeparator: "__",
+		EnvLowerCase: true,
+	})
+
+	assert.Equal(t, "mapHost", dbBag.ReadString("host", ""))
+	assert.Equal(t, 20, dbBag.ReadInt("max-conns", 0))
+	assert.Equal(
+		t,
+		map[string]interface{}{"host": "mapHost", "max__conns": "20", "password": "secret"},
+		dbBag.BaseValuesProvider.ToKeyValues(),
+	)
+
+	b := &bytes.Buffer{}
+	err = dbBag.BaseValuesProvider.Dump(b)
+	assert.NoError(t, err)
+	assert.NotContains(t, b.String(), "secret")
+	assert.Contains(t, b.String(), `"host":"mapHost"`)
+}
+
+func TestNestedSubBagsKeepParentOptions(t *testing.T) {
+	t.Setenv("APP_DB_REPLICA_HOST", "apphost")
+	t.Setenv("DB_REPLICA_HOST", "plainhost")
+	t.Setenv("APP_DB_REPLICA_BACKUP_HOST", "appbackuphost")
+	t.Setenv("DB_REPLICA_BACKUP_HOST", "plainbackuphost")
+
+	dbBag := New(&EnvValuesProvider{}).SubWithOptions("db", SubOptions{EnvPrefix: "APP"})
+	assert.Equal(t, "apphost", dbBag.ReadString("replica.host", ""))
+
+	replicaBag := dbBag.Sub("replica")
+	assert.Equal(t, "apphost", replicaBag.ReadString("host", ""))
+	assert.Equal(t, "appbackuphost", replicaBag.Sub("backup").ReadString("host", ""))
+
+	plainReplicaBag := dbBag.SubWithOptions("replica", SubOptions{})
+	assert.Equal(t, "plainhost", plainReplicaBag.ReadString("host", ""))
+	assert.Equal(t, "plainbackuphost", plainReplicaBag.Sub("backup").ReadString("host", ""))
+
+	t.Setenv("SVC__DB__REPLICA__PORT", "5433")
+	mapProvider := NewMapValuesProvider(map[string]interface{}{"db/replica/host": "mapHost"})
+	customBag := New(NewValuesProviderComposite(&EnvValuesProvider{}, mapProvider)).
+		SubWithOptions("db", SubOptions{Separator: "/", EnvPrefix: "svc", EnvSeparator: "__"}).
+		Sub("replica")
+	assert.Equal(t, "mapHost", customBag.ReadString("host", ""))
+	assert.Equal(t, 5433, customBag.ReadInt("port", 0))
+}
+
+func TestSubOptionsEnvKey(t *testing.T) {
+	testCases := []struct {
+		opts        SubOptions
+		prefix      string
+		name        string
+		expectedKey string
+	}{
+		{SubOptions{}, "db", "host", "DB_HOST"},
+		{SubOptions{EnvPrefix: "app"}, "db", "max-conns", "APP_DB_MAX_CONNS"},
+		{SubOptions{EnvPrefix: "APP"}, "db.replica", "host", "APP_DB_REPLICA_HOST"},
+		{SubOptions{}, "", "db.host", "DB_HOST"},
+		{SubOptions{EnvPrefix: "APP"}, "db", "", "APP_DB_"},
+		{SubOptions{EnvSeparator: "__", EnvLowerCase: true}, "db", "host", "db__host"},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expectedKey, testCase.opts.envKey(testCase.prefix, testCase.name))
+	}
+}
+
+func TestSubOfDecoratedComposites(t *testing.T) {
+	t.Setenv("DB_HOST", "envhost")
+
+	jsonProvider, err := NewJSONValuesProvider(strings.NewReader(
+		`{"db":{"host":"filehost","user":"app","port":5432,"dsn":"${db.user}@${db.host}:${db.port}"}}`,
+	))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	comp := NewValuesProviderComposite(&EnvValuesProvider{}, jsonProvider)
+	encryptedProvider, err := NewEncryptedValuesProvider(comp, make([]byte, 32))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	decoratedProviders := map[string]ValuesProvider{
+		"composite":    comp,
+		"interpolated": NewInterpolatingValuesProvider(comp),
+		"file_ref":     NewFileRefValuesProvider(comp),
+		"encrypted":    encryptedProvider,
+		"labeled":      NewLabeledValuesProvider("cfg", comp),
+	}
+	for name, vp := range decoratedProviders {
+		dbBag := New(vp).Sub("db")
+		assert.Equal(t, "envhost", dbBag.ReadString("host", ""), name)
+		assert.Equal(t, 5432, dbBag.ReadInt("port", 0), name)
+	}
+
+	dbBag := New(NewInterpolatingValuesProvider(comp)).Sub("db")
+	assert.Equal(t, "app@filehost:5432", dbBag.ReadString("dsn", ""))
+
+	expl := dbBag.Explain("host")
+	assert.True(t, expl.Found)
+	assert.Equal(t, "envhost", expl.Value)
+	assert.Equal(t, "interpolated:env", expl.Source.Label)
+	if assert.Len(t, expl.Shadowed, 1) {
+		assert.Equal(t, "interpolated:json", expl.Shadowed[0].Label)
+		assert.Equal(t, "filehost", expl.Shadowed[0].Value)
+	}
+
+	expl = New(NewLabeledValuesProvider("cfg", comp)).Sub("db").Explain("host")
+	assert.Equal(t, "cfg:env", expl.Source.Label)
+
+	b := &bytes.Buffer{}
+	err = New(comp).Sub("db").DumpWithSources(b)
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), "host = \"envhost\" (env)\n    shadowed: \"filehost\" (json)\n")
+	assert.Contains(t, b.String(), "port = 5432 (json)\n")
+}
@@ -0,0 +1,114 @@
+package options
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/breathbath/go_utils/v3/pkg/env"
+)
+
+// ProfileEnv is the env variable with the name of the active config profile e.g. dev, staging or prod
+const ProfileEnv = "APP_PROFILE"
+
+// ProfileOptions defines which files are loaded by LoadProfile
+type ProfileOptions struct {
+	// BaseName is the name of config files without the extension, "config" is used if empty
+	BaseName string
+	// Extension defines the format of config files as in FileParserByExtension, ".json" is used if empty
+	Extension string
+	// OptionalProfileFile allows to skip a missing profile file, otherwise it's an error
+	OptionalProfileFile bool
+}
+
+// ProfileFromEnv gives the profile name from the APP_PROFILE env variable or an empty string if it's not set
+func ProfileFromEnv() string {
+	return env.ReadEnv(ProfileEnv, "")
+}
+
+/*
+LoadProfile reads the base config file e.g. config.json and the profile file e.g. config.prod.json from dir and gives
+a bag where env variables take precedence over the profile file and the profile file over the base file, nested objects
+of the files are merged, so the profile file needs to contain only the changed options, env variables are named
+by EnvNameFromKey e.g. DB_HOST overrides db.host, see EnvOverridesValuesProvider,
+an empty profile gives the base file with env variables, the layers are named as env and file:<path> in Explain outputs
+*/
+func LoadProfile(dir, profile string, opts ProfileOptions) (*ParameterBag, error) {
+	if strings.ContainsAny(profile, `/\`) || profile == "." || profile == ".." {
+		return nil, fmt.Errorf("invalid config profile name %q", profile)
+	}
+
+	baseName := opts.BaseName
+	if baseName == "" {
+		baseName = "config"
+	}
+
+	ext := opts.Extension
+	if ext == "" {
+		ext = ".json"
+	}
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+
+	baseFilePath := filepath.Join(dir, baseName+ext)
+	baseVals, err := readProfileFile(baseFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	fileLayers := []Layer{{Name: "file:" + baseFilePath, Provider: baseVals}}
+	if profile != "" {
+		profileLayer, found, err := readProfileLayer(filepath.Join(dir, baseName+"."+profile+ext), profile, opts)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			fileLayers = append([]Layer{profileLayer}, fileLayers...)
+		}
+	}
+
+	envLayer := Layer{
+		Name:     "env",
+		Provider: NewEnvOverridesValuesProvider(NewLayeredValuesProviderComposite(fileLayers...)),
+	}
+
+	return New(NewLayeredValuesProviderComposite(append([]Layer{envLayer}, fileLayers...)...)), nil
+}
+
+func readProfileLayer(profileFilePath, profile string, opts ProfileOptions) (layer Layer, found bool, err error) {
+	_, err = os.Stat(profileFilePath)
+	if os.IsNotExist(err) {
+		if opts.OptionalProfileFile {
+			return layer, false, nil
+		}
+		return layer, false, fmt.Errorf("config file %s of profile %s is not found", profileFilePath, profile)
+	}
+
+	profileVals, err := readProfileFile(profileFilePath)
+	if err != nil {
+		return layer, false, err
+	}
+
+	return Layer{Name: "file:" + profileFilePath, Provider: profileVals}, true, nil
+}
+
+func readProfileFile(filePath string) (*MapValuesProvider, error) {
+	parser, err := FileParserByExtension(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %v", filePath, err)
+	}
+
+	vals, err := parser(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %v", filePath, err)
+	}
+
+	return NewMapValuesProvider(vals), nil
+}
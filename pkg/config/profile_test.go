@@ -0,0 +1,112 @@
+package options
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeProfileFiles(t *testing.T) string {
+	dir := t.TempDir()
+	writeConfigFile(t, filepath.Join(dir, "config.json"), `{
+		"name": "app",
+		"db": {"host": "localhost", "port": 5432},
+		"PROFILE_TEST_TIMEOUT": 10
+	}`, time.Now())
+	writeConfigFile(t, filepath.Join(dir, "config.prod.json"), `{"db": {"host": "prod-db"}}`, time.Now())
+	writeConfigFile(t, filepath.Join(dir, "app.staging.yaml"), "db:\n  host: staging-db\n", time.Now())
+	writeConfigFile(t, filepath.Join(dir, "app.yaml"), "name: yamlApp\n", time.Now())
+
+	return dir
+}
+
+func TestLoadProfile(t *testing.T) {
+	dir := writeProfileFiles(t)
+	t.Setenv("PROFILE_TEST_TIMEOUT", "30")
+
+	pb, err := LoadProfile(dir, "prod", ProfileOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "app", pb.ReadString("name", ""))
+	assert.Equal(t, "prod-db", pb.ReadString("db.host", ""))
+	assert.Equal(t, 5432, pb.ReadInt("db.port", 0))
+	assert.Equal(t, 30, pb.ReadInt("PROFILE_TEST_TIMEOUT", 0))
+
+	dbVal, found := pb.Read("db", nil)
+	assert.True(t, found)
+	assert.Equal(t, map[string]interface{}{"host": "prod-db", "port": 5432}, dbVal)
+	assert.Equal(t, dbVal, pb.BaseValuesProvider.ToKeyValues()["db"])
+
+	expl := pb.Explain("db.host")
+	assert.Equal(t, "file:"+filepath.Join(dir, "config.prod.json"), expl.Source.Label)
+	assert.Equal(t, "file:"+filepath.Join(dir, "config.json"), expl.Shadowed[0].Label)
+
+	basePb, err := LoadProfile(dir, "", ProfileOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "localhost", basePb.ReadString("db.host", ""))
+
+	yamlPb, err := LoadProfile(dir, "staging", ProfileOptions{BaseName: "app", Extension: "yaml"})
+	require.NoError(t, err)
+	assert.Equal(t, "yamlApp", yamlPb.ReadString("name", ""))
+	assert.Equal(t, "staging-db", yamlPb.ReadString("db.host", ""))
+}
+
+func TestLoadProfileEnvOverridesNestedKeys(t *testing.T) {
+	dir := writeProfileFiles(t)
+	t.Setenv("DB_HOST", "envhost")
+
+	pb, err := LoadProfile(dir, "prod", ProfileOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "envhost", pb.ReadString("db.host", ""))
+	assert.Equal(t, "envhost", pb.Sub("db").ReadString("host", ""))
+	assert.Equal(t, 5432, pb.ReadInt("db.port", 0))
+
+	dbVal, found := pb.Read("db", nil)
+	assert.True(t, found)
+	assert.Equal(t, map[string]interface{}{"host": "envhost", "port": 5432}, dbVal)
+
+	expl := pb.Explain("db.host")
+	assert.Equal(t, "env", expl.Source.Label)
+	assert.Len(t, expl.Shadowed, 2)
+
+	basePb, err := LoadProfile(dir, "", ProfileOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "envhost", basePb.ReadString("db.host", ""))
+}
+
+func TestLoadProfileMissingFiles(t *testing.T) {
+	dir := writeProfileFiles(t)
+
+	_, err := LoadProfile(dir, "dev", ProfileOptions{})
+	assert.EqualError(t, err, "config file "+filepath.Join(dir, "config.dev.json")+" of profile dev is not found")
+
+	pb, err := LoadProfile(dir, "dev", ProfileOptions{OptionalProfileFile: true})
+	require.NoError(t, err)
+	assert.Equal(t, "localhost", pb.ReadString("db.host", ""))
+
+	_, err = LoadProfile(dir, "prod", ProfileOptions{BaseName: "missing"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read config file "+filepath.Join(dir, "missing.json"))
+
+	_, err = LoadProfile(dir, "../prod", ProfileOptions{})
+	assert.EqualError(t, err, `invalid config profile name "../prod"`)
+
+	writeConfigFile(t, filepath.Join(dir, "config.broken.json"), "{", time.Now())
+	_, err = LoadProfile(dir, "broken", ProfileOptions{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse config file "+filepath.Join(dir, "config.broken.json"))
+}
+
+func TestProfileFromEnv(t *testing.T) {
+	t.Setenv(ProfileEnv, "staging")
+	assert.Equal(t, "staging", ProfileFromEnv())
+
+	err := os.Unsetenv(ProfileEnv)
+	require.NoError(t, err)
+	assert.Equal(t, "", ProfileFromEnv())
+}
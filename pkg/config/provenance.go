@@ -0,0 +1,278 @@
+package options
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Labeler is implemented by values providers which can tell where their values come from e.g. env or file:config.json
+type Labeler interface {
+	Label() string
+}
+
+// ProvidersContainer is implemented by values providers which combine other providers e.g. ValuesProviderComposite
+type ProvidersContainer interface {
+	Providers() []ValuesProvider
+}
+
+/*
+ProviderDecorator is implemented by values providers which change values of a wrapped provider
+e.g. InterpolatingValuesProvider, if the wrapped provider combines other providers, sub bags, explanations and dumps
+with sources see its leaf providers wrapped with Decorate, so each leaf keeps its own key style and label
+*/
+type ProviderDecorator interface {
+	// DecoratedProvider gives the wrapped provider
+	DecoratedProvider() ValuesProvider
+	// Decorate wraps vp the same way as the wrapped provider
+	Decorate(vp ValuesProvider) ValuesProvider
+}
+
+// LabeledValuesProvider gives a custom label to the wrapped values provider which is shown in explanations
+type LabeledValuesProvider struct {
+	ValuesProvider
+	label string
+}
+
+// NewLabeledValuesProvider wraps vp to show it as label in Explain and DumpWithSources outputs
+func NewLabeledValuesProvider(label string, vp ValuesProvider) *LabeledValuesProvider {
+	return &LabeledValuesProvider{
+		ValuesProvider: vp,
+		label:          label,
+	}
+}
+
+func (lvp *LabeledValuesProvider) Label() string {
+	return lvp.label
+}
+
+// DumpFormatted outputs values of the wrapped values provider in the given format, see DumpFormatted
+func (lvp *LabeledValuesProvider) DumpFormatted(w io.Writer, format DumpFormat) error {
+	return DumpFormatted(lvp.ValuesProvider, w, format)
+}
+
+func (lvp *LabeledValuesProvider) DecoratedProvider() ValuesProvider {
+	return lvp.ValuesProvider
+}
+
+// Decorate labels vp with the label of the provider followed by the label of vp e.g. defaults:env
+func (lvp *LabeledValuesProvider) Decorate(vp ValuesProvider) ValuesProvider {
+	return NewLabeledValuesProvider(lvp.label+":"+ProviderLabel(vp), vp)
+}
+
+// ReadWithError reads name from the wrapped values provider and gives its read error
+func (lvp *LabeledValuesProvider) ReadWithError(name string) (val interface{}, found bool, err error) {
+	return ReadValue(lvp.ValuesProvider, name)
+}
+
+// KeyStyle gives the key style of the wrapped values provider
+func (lvp *LabeledValuesProvider) KeyStyle() KeyStyle {
+	return ProviderKeyStyle(lvp.ValuesProvider)
+}
+
+// HasStringValues tells if the wrapped values provider gives plain string values, see StringValuesProvider
+func (lvp *LabeledValuesProvider) HasStringValues() bool {
+	svp, ok := lvp.ValuesProvider.(StringValuesProvider)
+	return ok && svp.HasStringValues()
+}
+
+// ProviderLabel gives the label of vp or its type name if vp doesn't implement Labeler
+func ProviderLabel(vp ValuesProvider) string {
+	if labeler, ok := vp.(Labeler); ok {
+		return labeler.Label()
+	}
+
+	return fmt.Sprintf("%T", vp)
+}
+
+// ValueSource describes a value given by a values provider
+type ValueSource struct {
+	Label    string
+	Provider ValuesProvider
+	Value    interface{}
+}
+
+// Explanation tells which provider gives the value of an option and which values of other providers are shadowed by it
+type Explanation struct {
+	Name     string
+	Found    bool
+	Value    interface{}
+	Source   ValueSource
+	Shadowed []ValueSource
+}
+
+// ExplainValue reads name from all providers which are combined in vp in their precedence order
+func ExplainValue(vp ValuesProvider, name string) Explanation {
+	expl := Explanation{
+		Name:     name,
+		Shadowed: []ValueSource{},
+	}
+
+	leaves, translateKey := sourceLeaves(vp)
+	for _, leafProvider := range leaves {
+		val, found := leafProvider.Read(translateKey(leafProvider, name))
+		if !found {
+			continue
+		}
+
+		source := ValueSource{
+			Label:    ProviderLabel(leafProvider),
+			Provider: leafProvider,
+			Value:    val,
+		}
+
+		if !expl.Found {
+			expl.Found = true
+			expl.Value = val
+			expl.Source = source
+			continue
+		}
+
+		expl.Shadowed = append(expl.Shadowed, source)
+	}
+
+	return expl
+}
+
+// leafProviders gives the providers combined in vp in their precedence order, leaves of a decorated composite
+// are wrapped by the decorator, so a leaf never combines other providers
+func leafProviders(vp ValuesProvider) []ValuesProvider {
+	if vp == nil {
+		return []ValuesProvider{}
+	}
+
+	if container, ok := vp.(ProvidersContainer); ok {
+		res := []ValuesProvider{}
+		for _, childProvider := range container.Providers() {
+			res = append(res, leafProviders(childProvider)...)
+		}
+		return res
+	}
+
+	decorator, ok := vp.(ProviderDecorator)
+	if !ok || !combinesProviders(decorator.DecoratedProvider()) {
+		return []ValuesProvider{vp}
+	}
+
+	res := []ValuesProvider{}
+	for _, leafProvider := range leafProviders(decorator.DecoratedProvider()) {
+		res = append(res, decorator.Decorate(leafProvider))
+	}
+
+	return res
+}
+
+/*
+sourceLeaves gives the leaf providers which give values of vp and a function which converts an option name of vp
+to the key of a leaf, leaves of a sub bag are the leaves of the parent bag with keys translated
+to the sub bag prefix e.g. host is read as DB_HOST from env providers of Sub("db")
+*/
+func sourceLeaves(vp ValuesProvider) (leaves []ValuesProvider, translateKey func(leaf ValuesProvider, name string) string) {
+	if pvp, ok := vp.(*PrefixedValuesProvider); ok {
+		return leafProviders(pvp.base), pvp.translateKey
+	}
+
+	return leafProviders(vp), func(leaf ValuesProvider, name string) string {
+		return name
+	}
+}
+
+// leafKeyValues gives values of a leaf of vp with the option names of vp, see sourceLeaves
+func leafKeyValues(vp, leafProvider ValuesProvider) map[string]interface{} {
+	if pvp, ok := vp.(*PrefixedValuesProvider); ok {
+		return pvp.leafKeyValues(leafProvider)
+	}
+
+	return leafProvider.ToKeyValues()
+}
+
+// combinesProviders tells if vp is a container of providers or a decorator of a container
+func combinesProviders(vp ValuesProvider) bool {
+	switch typedVP := vp.(type) {
+	case ProvidersContainer:
+		return true
+	case ProviderDecorator:
+		return combinesProviders(typedVP.DecoratedProvider())
+	default:
+		return false
+	}
+}
+
+// Explain tells which values provider gives the value of an option and which values are shadowed by it
+func (p *ParameterBag) Explain(name string) Explanation {
+	return ExplainValue(p.BaseValuesProvider, name)
+}
+
+/*
+DumpWithSources outputs all options sorted by name with the label of the provider which gives the value
+and the shadowed values of other providers, sensitive values are masked by CurrentRedactor e.g.
+
+	db_host = "localhost" (env)
+	    shadowed: "db" (file:config.json)
+	db_port = 5432 (file:config.json)
+*/
+func (p *ParameterBag) DumpWithSources(w io.Writer) error {
+	leaves, _ := sourceLeaves(p.BaseValuesProvider)
+	leavesKvs := make([]map[string]interface{}, 0, len(leaves))
+	names := map[string]bool{}
+	for _, leafProvider := range leaves {
+		kvs := leafKeyValues(p.BaseValuesProvider, leafProvider)
+		for name := range kvs {
+			names[name] = true
+		}
+		leavesKvs = append(leavesKvs, kvs)
+	}
+
+	sortedNames := make([]string, 0, len(names))
+	for name := range names {
+		sortedNames = append(sortedNames, name)
+	}
+	sort.Strings(sortedNames)
+
+	for _, name := range sortedNames {
+		err := writeValueSources(w, name, leaves, leavesKvs)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeValueSources outputs values from ToKeyValues rather than from Read, so values which providers mask
+// in ToKeyValues e.g. decrypted values of EncryptedValuesProvider stay masked
+func writeValueSources(w io.Writer, name string, leaves []ValuesProvider, leavesKvs []map[string]interface{}) error {
+	isShadowed := false
+	for i, kvs := range leavesKvs {
+		val, found := kvs[name]
+		if !found {
+			continue
+		}
+
+		format := "%s = %s (%s)\n"
+		args := []interface{}{name, formatSourceValue(name, val), ProviderLabel(leaves[i])}
+		if isShadowed {
+			format = "    shadowed: %s (%s)\n"
+			args = args[1:]
+		}
+		isShadowed = true
+
+		_, err := fmt.Fprintf(w, format, args...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func formatSourceValue(name string, val interface{}) string {
+	val = CurrentRedactor().RedactValue(name, val)
+	data, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Sprint(val)
+	}
+
+	return string(data)
+}
@@ -0,0 +1,111 @@
+package options
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParameterBagExplain(t *testing.T) {
+	err := os.Setenv("SOME_EXPLAIN_HOST", "envHost")
+	assert.NoError(t, err)
+	defer func() {
+		err = os.Unsetenv("SOME_EXPLAIN_HOST")
+		if err != nil {
+			log.Println(err.Error())
+		}
+	}()
+
+	jvp, err := NewJSONValuesProvider(strings.NewReader(`{"SOME_EXPLAIN_HOST":"jsonHost","SOME_EXPLAIN_PORT":80}`))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	overrides := NewLabeledValuesProvider("overrides", NewMapValuesProvider(map[string]interface{}{
+		"SOME_EXPLAIN_PORT": 8080,
+	}))
+
+	pb := New(NewValuesProviderComposite(&EnvValuesProvider{}, jvp))
+	pb.MergeParameterBag(New(overrides))
+
+	expl := pb.Explain("SOME_EXPLAIN_HOST")
+	assert.True(t, expl.Found)
+	assert.Equal(t, "SOME_EXPLAIN_HOST", expl.Name)
+	assert.Equal(t, "envHost", expl.Value)
+	assert.Equal(t, "env", expl.Source.Label)
+	assert.Equal(t, "envHost", expl.Source.Value)
+	assert.Len(t, expl.Shadowed, 1)
+	assert.Equal(t, "json", expl.Shadowed[0].Label)
+	assert.Equal(t, "jsonHost", expl.Shadowed[0].Value)
+	assert.Equal(t, jvp, expl.Shadowed[0].Provider)
+
+	expl2 := pb.Explain("SOME_EXPLAIN_PORT")
+	assert.True(t, expl2.Found)
+	assert.Equal(t, 80, expl2.Value)
+	assert.Equal(t, "json", expl2.Source.Label)
+	assert.Equal(t, []ValueSource{{Label: "overrides", Provider: overrides, Value: 8080}}, expl2.Shadowed)
+
+	expl3 := pb.Explain("SOME_EXPLAIN_MISSING")
+	assert.False(t, expl3.Found)
+	assert.Nil(t, expl3.Value)
+	assert.Len(t, expl3.Shadowed, 0)
+
+	expl4 := New(nil).Explain("SOME_EXPLAIN_HOST")
+	assert.False(t, expl4.Found)
+}
+
+type unlabeledValuesProviderMock struct{}
+
+func (uvpm *unlabeledValuesProviderMock) Read(name string) (val interface{}, found bool) {
+	return
+}
+
+func (uvpm *unlabeledValuesProviderMock) Dump(w io.Writer) (err error) {
+	return
+}
+
+func (uvpm *unlabeledValuesProviderMock) ToKeyValues() map[string]interface{} {
+	return map[string]interface{}{}
+}
+
+func TestProviderLabel(t *testing.T) {
+	assert.Equal(t, "map", ProviderLabel(NewMapValuesProvider(nil)))
+	assert.Equal(t, "env", ProviderLabel(&EnvValuesProvider{}))
+	assert.Equal(t, "null", ProviderLabel(&NullValuesProvider{}))
+	assert.Equal(t, "custom", ProviderLabel(NewLabeledValuesProvider("custom", &EnvValuesProvider{})))
+	assert.Equal(t, "*options.unlabeledValuesProviderMock", ProviderLabel(&unlabeledValuesProviderMock{}))
+}
+
+func TestParameterBagDumpWithSources(t *testing.T) {
+	jvp, err := NewJSONValuesProvider(strings.NewReader(`{"host":"jsonHost","port":80,"tags":["a","b"]}`))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	mvp := NewMapValuesProvider(map[string]interface{}{
+		"host": "mapHost",
+	})
+
+	pb := New(NewValuesProviderComposite(
+		NewLabeledValuesProvider("defaults", mvp),
+		NewLabeledValuesProvider("file:config.json", jvp),
+	))
+
+	b := &bytes.Buffer{}
+	err = pb.DumpWithSources(b)
+	assert.NoError(t, err)
+
+	expectedOutput := `host = "mapHost" (defaults)
+    shadowed: "jsonHost" (file:config.json)
+port = 80 (file:config.json)
+tags = ["a","b"] (file:config.json)
+`
+	assert.Equal(t, expectedOutput, b.String())
+}
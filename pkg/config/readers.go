@@ -0,0 +1,440 @@
+package options
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/breathbath/go_utils/v3/pkg/types"
+)
+
+// DefaultTimeLayouts are used by ReadTime if no layouts are given
+var DefaultTimeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"}
+
+var byteSizeUnits = map[string]uint64{
+	"":    1,
+	"b":   1,
+	"kb":  1000,
+	"mb":  1000 * 1000,
+	"gb":  1000 * 1000 * 1000,
+	"tb":  1000 * 1000 * 1000 * 1000,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+}
+
+// ReadFloat64 same as Read but returns a float64
+func (p *ParameterBag) ReadFloat64(name string, defaultVal float64) float64 {
+	valI, found := p.read(name, "float64", defaultVal)
+	if !found {
+		return defaultVal
+	}
+
+	val, err := convertToFloat64(valI)
+	if err != nil {
+		return defaultVal
+	}
+
+	return val
+}
+
+// ReadRequiredFloat64 same as ReadRequired but returns float64 or error
+func (p *ParameterBag) ReadRequiredFloat64(name string) (float64, error) {
+	valI, err := p.readRequired(name, "float64")
+	if err != nil {
+		return 0, err
+	}
+
+	return convertToFloat64(valI)
+}
+
+func convertToFloat64(valI interface{}) (float64, error) {
+	switch typedVal := valI.(type) {
+	case float64:
+		return typedVal, nil
+	case float32:
+		return float64(typedVal), nil
+	case types.Decimal:
+		return typedVal.ToFloat(), nil
+	}
+
+	val, err := strconv.ParseFloat(strings.TrimSpace(fmt.Sprint(valI)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot convert %v to float", valI)
+	}
+
+	return val, nil
+}
+
+// ReadDecimal same as Read but returns types.Decimal, use it for money values to avoid float rounding errors
+func (p *ParameterBag) ReadDecimal(name string, defaultVal types.Decimal) types.Decimal {
+	valI, found := p.read(name, "decimal", defaultVal)
+	if !found {
+		return defaultVal
+	}
+
+	val, err := convertToDecimal(valI)
+	if err != nil {
+		return defaultVal
+	}
+
+	return val
+}
+
+// ReadRequiredDecimal same as ReadRequired but returns types.Decimal or error
+func (p *ParameterBag) ReadRequiredDecimal(name string) (types.Decimal, error) {
+	valI, err := p.readRequired(name, "decimal")
+	if err != nil {
+		return types.Decimal{}, err
+	}
+
+	return convertToDecimal(valI)
+}
+
+func convertToDecimal(valI interface{}) (types.Decimal, error) {
+	switch typedVal := valI.(type) {
+	case types.Decimal:
+		return typedVal, nil
+	case float64:
+		return types.NewDecimalFromFloat(typedVal), nil
+	case float32:
+		return types.NewDecimalFromFloat(float64(typedVal)), nil
+	}
+
+	valStr := strings.TrimSpace(fmt.Sprint(valI))
+	dec := types.Decimal{}
+	err := dec.UnmarshalJSON([]byte(valStr))
+	if err != nil || valStr == "" {
+		return types.Decimal{}, fmt.Errorf("cannot convert %v to decimal", valI)
+	}
+
+	return dec, nil
+}
+
+// ReadURL same as Read but returns an absolute url with a scheme and a host e.g. https://example.com/api
+func (p *ParameterBag) ReadURL(name string, defaultVal *url.URL) *url.URL {
+	valI, found := p.read(name, "url", defaultVal)
+	if !found {
+		return defaultVal
+	}
+
+	val, err := convertToURL(valI)
+	if err != nil {
+		return defaultVal
+	}
+
+	return val
+}
+
+// ReadRequiredURL same as ReadRequired but returns an absolute url or error
+func (p *ParameterBag) ReadRequiredURL(name string) (*url.URL, error) {
+	valI, err := p.readRequired(name, "url")
+	if err != nil {
+		return nil, err
+	}
+
+	return convertToURL(valI)
+}
+
+func convertToURL(valI interface{}) (*url.URL, error) {
+	switch typedVal := valI.(type) {
+	case *url.URL:
+		return typedVal, nil
+	case url.URL:
+		return &typedVal, nil
+	}
+
+	valStr := strings.TrimSpace(fmt.Sprint(valI))
+	parsedURL, err := url.Parse(valStr)
+	if err != nil || parsedURL.Scheme == "" || parsedURL.Host == "" {
+		return nil, fmt.Errorf("%s is not a valid absolute url", valStr)
+	}
+
+	return parsedURL, nil
+}
+
+// ReadTime same as Read but returns time.Time parsed with the first matching layout, DefaultTimeLayouts are used
+// if no layouts are given
+func (p *ParameterBag) ReadTime(name string, defaultVal time.Time, layouts ...string) time.Time {
+	valI, found := p.read(name, "time", defaultVal)
+	if !found {
+		return defaultVal
+	}
+
+	val, err := convertToTime(valI, layouts)
+	if err != nil {
+		return defaultVal
+	}
+
+	return val
+}
+
+// ReadRequiredTime same as ReadRequired but returns time.Time or error
+func (p *ParameterBag) ReadRequiredTime(name string, layouts ...string) (time.Time, error) {
+	valI, err := p.readRequired(name, "time")
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return convertToTime(valI, layouts)
+}
+
+func convertToTime(valI interface{}, layouts []string) (time.Time, error) {
+	if val, ok := valI.(time.Time); ok {
+		return val, nil
+	}
+
+	if len(layouts) == 0 {
+		layouts = DefaultTimeLayouts
+	}
+
+	valStr := strings.TrimSpace(fmt.Sprint(valI))
+	for _, layout := range layouts {
+		val, err := time.Parse(layout, valStr)
+		if err == nil {
+			return val, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("cannot convert %v to time with layouts %s", valI, strings.Join(layouts, ", "))
+}
+
+// ReadByteSize same as Read but returns the number of bytes of a size e.g. 512, 10MB or 1.5GiB, see ParseByteSize
+func (p *ParameterBag) ReadByteSize(name string, defaultVal uint64) uint64 {
+	valI, found := p.read(name, "byte_size", defaultVal)
+	if !found {
+		return defaultVal
+	}
+
+	val, err := ParseByteSize(fmt.Sprint(valI))
+	if err != nil {
+		return defaultVal
+	}
+
+	return val
+}
+
+// ReadRequiredByteSize same as ReadRequired but returns the number of bytes or error
+func (p *ParameterBag) ReadRequiredByteSize(name string) (uint64, error) {
+	valI, err := p.readRequired(name, "byte_size")
+	if err != nil {
+		return 0, err
+	}
+
+	return ParseByteSize(fmt.Sprint(valI))
+}
+
+/*
+ParseByteSize converts a size with an optional unit to the number of bytes, units are case insensitive,
+KB, MB, GB and TB are powers of 1000 and KiB, MiB, GiB and TiB are powers of 1024 e.g. 10MB gives 10000000,
+1.5 KiB gives 1536 and 512 or 512B give 512
+*/
+func ParseByteSize(size string) (uint64, error) {
+	size = strings.TrimSpace(size)
+	unitIndex := strings.IndexFunc(size, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsSpace(r)
+	})
+	if unitIndex < 0 {
+		unitIndex = len(size)
+	}
+
+	numStr, unit := size[:unitIndex], strings.ToLower(strings.TrimSpace(size[unitIndex:]))
+	multiplier, isKnownUnit := byteSizeUnits[unit]
+	num, err := strconv.ParseFloat(numStr, 64)
+	if !isKnownUnit || err != nil || num < 0 {
+		return 0, fmt.Errorf("cannot convert %s to byte size", size)
+	}
+
+	bytesCount := num * float64(multiplier)
+	if bytesCount >= math.MaxUint64 {
+		return 0, fmt.Errorf("byte size %s is too big", size)
+	}
+
+	return uint64(bytesCount), nil
+}
+
+// ReadStringMap same as Read but returns map[string]string from an object or a string like "a=1,b=2" or {"a": "1"}
+func (p *ParameterBag) ReadStringMap(name string, defaultVal map[string]string) map[string]string {
+	valI, found := p.read(name, "string_map", defaultVal)
+	if !found {
+		return defaultVal
+	}
+
+	val, err := convertToStringMap(valI)
+	if err != nil {
+		return defaultVal
+	}
+
+	return val
+}
+
+// ReadRequiredStringMap same as ReadRequired but returns map[string]string or error
+func (p *ParameterBag) ReadRequiredStringMap(name string) (map[string]string, error) {
+	valI, err := p.readRequired(name, "string_map")
+	if err != nil {
+		return nil, err
+	}
+
+	return convertToStringMap(valI)
+}
+
+func convertToStringMap(valI interface{}) (map[string]string, error) {
+	switch typedVal := valI.(type) {
+	case map[string]string:
+		return typedVal, nil
+	case map[string]interface{}:
+		res := make(map[string]string, len(typedVal))
+		for key, val := range typedVal {
+			switch val.(type) {
+			case map[string]interface{}, []interface{}:
+				return nil, fmt.Errorf("cannot convert nested value of %s to string", key)
+			case nil:
+				res[key] = ""
+			default:
+				res[key] = fmt.Sprint(val)
+			}
+		}
+		return res, nil
+	case string:
+		return parseStringMap(typedVal)
+	default:
+		return nil, fmt.Errorf("cannot convert %v to map[string]string", valI)
+	}
+}
+
+// parseStringMap parses a json object or comma separated key=value pairs
+func parseStringMap(valStr string) (map[string]string, error) {
+	valStr = strings.TrimSpace(valStr)
+	if strings.HasPrefix(valStr, "{") {
+		obj := map[string]interface{}{}
+		err := json.Unmarshal([]byte(valStr), &obj)
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert %s to map[string]string: %v", valStr, err)
+		}
+		return convertToStringMap(obj)
+	}
+
+	res := map[string]string{}
+	if valStr == "" {
+		return res, nil
+	}
+
+	for _, pair := range strings.Split(valStr, ",") {
+		keyVal := strings.SplitN(pair, "=", 2)
+		key := strings.TrimSpace(keyVal[0])
+		if len(keyVal) != 2 || key == "" {
+			return nil, fmt.Errorf("invalid map item %q, key=value is expected", strings.TrimSpace(pair))
+		}
+		res[key] = strings.TrimSpace(keyVal[1])
+	}
+
+	return res, nil
+}
+
+// ReadIntSlice same as ReadStrings but returns []int
+func (p *ParameterBag) ReadIntSlice(name string, defaultVal ...int) []int {
+	valI, found := p.read(name, "int_slice", defaultVal)
+	if !found {
+		return defaultVal
+	}
+
+	val, err := p.convertToIntSlice(name, valI)
+	if err != nil || isEmptyString(valI) {
+		return defaultVal
+	}
+
+	return val
+}
+
+// ReadRequiredIntSlice same as ReadRequired but returns []int or error
+func (p *ParameterBag) ReadRequiredIntSlice(name string) ([]int, error) {
+	valI, err := p.readRequired(name, "int_slice")
+	if err != nil {
+		return nil, err
+	}
+
+	return p.convertToIntSlice(name, valI)
+}
+
+func (p *ParameterBag) convertToIntSlice(name string, valI interface{}) ([]int, error) {
+	if ints, ok := valI.([]int); ok {
+		return ints, nil
+	}
+
+	items, ok := p.convertToList(name, valI)
+	if !ok {
+		return nil, fmt.Errorf("cannot convert %v to []int", valI)
+	}
+
+	res := make([]int, 0, len(items))
+	for _, item := range items {
+		intVal, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert %v to []int", valI)
+		}
+		res = append(res, intVal)
+	}
+
+	return res, nil
+}
+
+// ReadSecret same as Read but returns types.Secret which is masked in all outputs
+func (p *ParameterBag) ReadSecret(name string, defaultVal types.Secret) types.Secret {
+	valI, found := p.read(name, "secret", defaultVal)
+	if !found {
+		return defaultVal
+	}
+
+	val, err := convertToSecret(valI)
+	if err != nil {
+		return defaultVal
+	}
+
+	return val
+}
+
+// ReadRequiredSecret same as ReadRequired but returns types.Secret or error if the value is not found or empty
+func (p *ParameterBag) ReadRequiredSecret(name string) (types.Secret, error) {
+	valI, err := p.readRequired(name, "secret")
+	if err != nil {
+		return types.Secret{}, err
+	}
+
+	val, err := convertToSecret(valI)
+	if err != nil {
+		return types.Secret{}, err
+	}
+
+	if val.IsEmpty() {
+		return val, fmt.Errorf("required option %s is empty", name)
+	}
+
+	return val, nil
+}
+
+// convertToSecret doesn't output the value in errors as it's sensitive
+func convertToSecret(valI interface{}) (types.Secret, error) {
+	switch typedVal := valI.(type) {
+	case types.Secret:
+		return typedVal, nil
+	case *types.Secret:
+		if typedVal == nil {
+			return types.Secret{}, nil
+		}
+		return *typedVal, nil
+	case string:
+		return types.NewSecret(typedVal), nil
+	case nil:
+		return types.Secret{}, nil
+	case map[string]interface{}, []interface{}:
+		return types.Secret{}, fmt.Errorf("cannot convert %T to secret", valI)
+	default:
+		return types.NewSecret(fmt.Sprint(valI)), nil
+	}
+}
@@ -0,0 +1,304 @@
+package options
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/breathbath/go_utils/v3/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFloat64(t *testing.T) {
+	pb := New(NewMapValuesProvider(map[string]interface{}{
+		"float":    0.25,
+		"int":      3,
+		"floatStr": " 1.5 ",
+		"str":      "high",
+	}))
+
+	assert.Equal(t, 0.25, pb.ReadFloat64("float", 1))
+	assert.Equal(t, 3.0, pb.ReadFloat64("int", 1))
+	assert.Equal(t, 1.5, pb.ReadFloat64("floatStr", 1))
+	assert.Equal(t, 1.0, pb.ReadFloat64("str", 1))
+	assert.Equal(t, 2.0, pb.ReadFloat64("missing", 2))
+
+	val, err := pb.ReadRequiredFloat64("floatStr")
+	assert.NoError(t, err)
+	assert.Equal(t, 1.5, val)
+
+	_, err = pb.ReadRequiredFloat64("str")
+	assert.EqualError(t, err, "cannot convert high to float")
+
+	_, err = pb.ReadRequiredFloat64("missing")
+	assert.EqualError(t, err, "required option missing is empty")
+}
+
+func TestReadDecimal(t *testing.T) {
+	pb := New(NewMapValuesProvider(map[string]interface{}{
+		"price":    "10.15",
+		"priceInt": 3,
+		"ratio":    0.5,
+		"str":      "cheap",
+	}))
+
+	assert.Equal(t, "10.15", pb.ReadDecimal("price", types.ZERO).String())
+	assert.Equal(t, "3", pb.ReadDecimal("priceInt", types.ZERO).String())
+	assert.Equal(t, "0.5", pb.ReadDecimal("ratio", types.ZERO).String())
+	assert.Equal(t, "1", pb.ReadDecimal("str", types.NewDecimalFromInt(1)).String())
+	assert.Equal(t, "2", pb.ReadDecimal("missing", types.NewDecimalFromInt(2)).String())
+
+	val, err := pb.ReadRequiredDecimal("price")
+	assert.NoError(t, err)
+	assert.True(t, val.Equal(types.NewDecimalFromString("10.15")))
+
+	_, err = pb.ReadRequiredDecimal("str")
+	assert.EqualError(t, err, "cannot convert cheap to decimal")
+}
+
+func TestReadURL(t *testing.T) {
+	defaultURL, err := url.Parse("http://localhost")
+	require.NoError(t, err)
+
+	pb := New(NewMapValuesProvider(map[string]interface{}{
+		"api":      "https://example.com:8443/v1?debug=1",
+		"relative": "/v1",
+	}))
+
+	apiURL := pb.ReadURL("api", defaultURL)
+	assert.Equal(t, "https", apiURL.Scheme)
+	assert.Equal(t, "example.com:8443", apiURL.Host)
+	assert.Equal(t, "/v1", apiURL.Path)
+	assert.Equal(t, defaultURL, pb.ReadURL("relative", defaultURL))
+	assert.Equal(t, defaultURL, pb.ReadURL("missing", defaultURL))
+
+	_, err = pb.ReadRequiredURL("relative")
+	assert.EqualError(t, err, "/v1 is not a valid absolute url")
+
+	_, err = pb.ReadRequiredURL("missing")
+	assert.EqualError(t, err, "required option missing is empty")
+}
+
+func TestReadTime(t *testing.T) {
+	yamlProvider, err := NewYAMLValuesProvider(strings.NewReader("yamlDate: 2021-03-04\n"))
+	require.NoError(t, err)
+
+	pb := New(NewValuesProviderComposite(
+		NewMapValuesProvider(map[string]interface{}{
+			"rfc":    "2021-03-04T05:06:07+02:00",
+			"date":   "2021-03-04",
+			"custom": "04.03.2021",
+			"str":    "yesterday",
+		}),
+		yamlProvider,
+	))
+	defaultTime := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	rfcTime := pb.ReadTime("rfc", defaultTime)
+	assert.True(t, rfcTime.Equal(time.Date(2021, 3, 4, 3, 6, 7, 0, time.UTC)))
+	assert.Equal(t, time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), pb.ReadTime("date", defaultTime))
+	assert.Equal(t, time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), pb.ReadTime("custom", defaultTime, "02.01.2006"))
+	assert.Equal(t, time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), pb.ReadTime("yamlDate", defaultTime))
+	assert.Equal(t, defaultTime, pb.ReadTime("custom", defaultTime))
+	assert.Equal(t, defaultTime, pb.ReadTime("missing", defaultTime))
+
+	_, err = pb.ReadRequiredTime("str", "02.01.2006", time.Kitchen)
+	assert.EqualError(t, err, "cannot convert yesterday to time with layouts 02.01.2006, 3:04PM")
+}
+
+func TestReadByteSize(t *testing.T) {
+	pb := New(NewMapValuesProvider(map[string]interface{}{
+		"int":  512,
+		"size": "10MB",
+		"str":  "big",
+	}))
+
+	assert.Equal(t, uint64(512), pb.ReadByteSize("int", 1))
+	assert.Equal(t, uint64(10000000), pb.ReadByteSize("size", 1))
+	assert.Equal(t, uint64(1), pb.ReadByteSize("str", 1))
+	assert.Equal(t, uint64(2), pb.ReadByteSize("missing", 2))
+
+	_, err := pb.ReadRequiredByteSize("str")
+	assert.EqualError(t, err, "cannot convert big to byte size")
+
+	testCases := map[string]uint64{
+		"0":        0,
+		"512B":     512,
+		"1.5 KiB":  1536,
+		"2kb":      2000,
+		"1GiB":     1 << 30,
+		" 3 TB ":   3000000000000,
+		"0.5mib":   1 << 19,
+		"10000000": 10000000,
+	}
+	for size, expectedBytes := range testCases {
+		bytesCount, err := ParseByteSize(size)
+		assert.NoError(t, err, size)
+		assert.Equal(t, expectedBytes, bytesCount, size)
+	}
+
+	for _, size := range []string{"", "MB", "-1MB", "10XB", "1e3"} {
+		_, err := ParseByteSize(size)
+		assert.Error(t, err, size)
+	}
+
+	_, err = ParseByteSize("100000000TiB")
+	assert.EqualError(t, err, "byte size 100000000TiB is too big")
+}
+
+func TestReadStringMap(t *testing.T) {
+	jsonProvider, err := NewJSONValuesProvider(strings.NewReader(`{"labels": {"app": "api", "replicas": 2, "empty": null}}`))
+	require.NoError(t, err)
+
+	pb := New(NewValuesProviderComposite(
+		NewMapValuesProvider(map[string]interface{}{
+			"pairs":   "a=1, b = 2,c=x=y",
+			"json":    `{"a": "1", "b": 2}`,
+			"invalid": "a=1,b",
+			"nested":  map[string]interface{}{"a": []interface{}{1}},
+		}),
+		jsonProvider,
+	))
+
+	assert.Equal(t, map[string]string{"a": "1", "b": "2", "c": "x=y"}, pb.ReadStringMap("pairs", nil))
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, pb.ReadStringMap("json", nil))
+	assert.Equal(t, map[string]string{"app": "api", "replicas": "2", "empty": ""}, pb.ReadStringMap("labels", nil))
+	assert.Equal(t, map[string]string{"d": "1"}, pb.ReadStringMap("invalid", map[string]string{"d": "1"}))
+	assert.Equal(t, map[string]string{"d": "2"}, pb.ReadStringMap("missing", map[string]string{"d": "2"}))
+
+	_, err = pb.ReadRequiredStringMap("invalid")
+	assert.EqualError(t, err, `invalid map item "b", key=value is expected`)
+
+	_, err = pb.ReadRequiredStringMap("nested")
+	assert.EqualError(t, err, "cannot convert nested value of a to string")
+}
+
+func TestReadIntSlice(t *testing.T) {
+	t.Setenv("READER_PORTS", "80, 443")
+	t.Setenv("READER_EMPTY", "")
+
+	pb := New(NewValuesProviderComposite(&EnvValuesProvider{}, NewMapValuesProvider(map[string]interface{}{
+		"ports":    "80, 443",
+		"list":     []interface{}{1, "2"},
+		"ints":     []int{3},
+		"empty":    "",
+		"invalid":  "80,http",
+		"single":   8080,
+		"strsList": []string{"4", "5"},
+	})))
+
+	assert.Equal(t, []int{80, 443}, pb.ReadIntSlice("READER_PORTS"))
+	assert.Equal(t, []int{1}, pb.ReadIntSlice("ports", 1))
+	assert.Equal(t, []int{1, 2}, pb.ReadIntSlice("list"))
+	assert.Equal(t, []int{3}, pb.ReadIntSlice("ints"))
+	assert.Equal(t, []int{1}, pb.ReadIntSlice("empty", 1))
+	assert.Equal(t, []int{1}, pb.ReadIntSlice("READER_EMPTY", 1))
+	assert.Equal(t, []int{4, 5}, pb.ReadIntSlice("strsList"))
+	assert.Equal(t, []int{1}, pb.ReadIntSlice("invalid", 1))
+	assert.Equal(t, []int{1}, pb.ReadIntSlice("single", 1))
+	assert.Equal(t, []int{1, 2}, pb.ReadIntSlice("missing", 1, 2))
+
+	_, err := pb.ReadRequiredIntSlice("invalid")
+	assert.EqualError(t, err, "cannot convert 80,http to []int")
+}
+
+func TestReadStringsOfDecoratedComposites(t *testing.T) {
+	t.Setenv("ZZ_LIST", "a,b")
+
+	jsonProvider, err := NewJSONValuesProvider(strings.NewReader(`{"file_list":"c,d"}`))
+	require.NoError(t, err)
+
+	comp := NewValuesProviderComposite(&EnvValuesProvider{}, jsonProvider)
+	encryptedProvider, err := NewEncryptedValuesProvider(comp, make([]byte, 32))
+	require.NoError(t, err)
+
+	decoratedProviders := map[string]ValuesProvider{
+		"interpolated": NewInterpolatingValuesProvider(comp),
+		"file_ref":     NewFileRefValuesProvider(comp),
+		"encrypted":    encryptedProvider,
+		"labeled":      NewLabeledValuesProvider("cfg", comp),
+		"nested":       NewInterpolatingValuesProvider(NewFileRefValuesProvider(comp)),
+	}
+	for name, vp := range decoratedProviders {
+		pb := New(vp)
+		assert.Equal(t, []string{"a", "b"}, pb.ReadStrings("ZZ_LIST"), name)
+		assert.Equal(t, []string{"c,d"}, pb.ReadStrings("file_list"), name)
+	}
+}
+
+func TestReadDurationStringsAndLists(t *testing.T) {
+	t.Setenv("READER_HOSTS", "a.com, b.com")
+
+	mapProvider := NewMapValuesProvider(map[string]interface{}{
+		"goDur":  "1m30s",
+		"intDur": "90",
+		"hosts":  "host=a,port=5",
+		"empty":  " ",
+	})
+	pb := New(NewValuesProviderComposite(&EnvValuesProvider{}, mapProvider))
+
+	assert.Equal(t, time.Second*90, pb.ReadDuration("goDur", time.Second, 1))
+	assert.Equal(t, time.Second*90, pb.ReadDuration("intDur", time.Second, 1))
+
+	dur, err := pb.ReadRequiredDuration("goDur", time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second*90, dur)
+
+	assert.Equal(t, []string{"a.com", "b.com"}, pb.ReadStrings("READER_HOSTS"))
+	assert.Equal(t, []string{"host=a,port=5"}, pb.ReadStrings("hosts"))
+	assert.Equal(t, []string{"default"}, pb.ReadStrings("empty", "default"))
+
+	hosts, err := pb.ReadRequiredStrings("READER_HOSTS")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.com", "b.com"}, hosts)
+
+	hosts, err = pb.ReadRequiredStrings("empty")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, hosts)
+
+	hosts, err = New(NewLabeledValuesProvider("env", &EnvValuesProvider{})).Sub("reader").ReadRequiredStrings("hosts")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.com", "b.com"}, hosts)
+
+	hosts, err = New(mapProvider).ReadRequiredStrings("hosts")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"host=a,port=5"}, hosts)
+}
+
+func TestReadSecret(t *testing.T) {
+	pb := New(NewMapValuesProvider(map[string]interface{}{
+		"password": "pass123",
+		"pin":      1234,
+		"secret":   types.NewSecret("token"),
+		"empty":    "",
+		"obj":      map[string]interface{}{"key": "val"},
+	}))
+	registry := NewUsageRegistry()
+	pb.TrackUsage(registry)
+
+	assert.Equal(t, "pass123", pb.ReadSecret("password", types.Secret{}).Reveal())
+	assert.Equal(t, "1234", pb.ReadSecret("pin", types.Secret{}).Reveal())
+	assert.Equal(t, "token", pb.ReadSecret("secret", types.Secret{}).Reveal())
+	assert.Equal(t, "default", pb.ReadSecret("obj", types.NewSecret("default")).Reveal())
+	assert.Equal(t, "default", pb.ReadSecret("missing", types.NewSecret("default")).Reveal())
+
+	val, err := pb.ReadRequiredSecret("password")
+	assert.NoError(t, err)
+	assert.Equal(t, "pass123", val.Reveal())
+
+	_, err = pb.ReadRequiredSecret("obj")
+	assert.EqualError(t, err, "cannot convert map[string]interface {} to secret")
+
+	_, err = pb.ReadRequiredSecret("empty")
+	assert.EqualError(t, err, "required option empty is empty")
+
+	_, err = pb.ReadRequiredSecret("missing2")
+	assert.EqualError(t, err, "required option missing2 is empty")
+
+	buf := &strings.Builder{}
+	err = registry.WriteHelp(buf)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "missing   secret  default ******")
+}
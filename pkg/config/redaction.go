@@ -0,0 +1,176 @@
+package options
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/breathbath/go_utils/v3/pkg/types"
+)
+
+// MaskedValue is shown instead of sensitive values in dumps
+const MaskedValue = types.SecretMask
+
+// DefaultSensitiveKeyPatterns are case insensitive patterns of option names which values are masked by default
+var DefaultSensitiveKeyPatterns = []string{
+	"*PASSWORD*",
+	"*PASSWD*",
+	"*SECRET*",
+	"*TOKEN*",
+	"*API_KEY*",
+	"*APIKEY*",
+	"*PRIVATE_KEY*",
+	"*CREDENTIALS*",
+}
+
+// Masker gives a replacement of a sensitive value
+type Masker func(key string, val interface{}) interface{}
+
+// DefaultMasker replaces values with MaskedValue, nil and empty values are kept to show that a value is not set
+func DefaultMasker(key string, val interface{}) interface{} {
+	if val == nil || val == "" {
+		return val
+	}
+
+	return MaskedValue
+}
+
+var (
+	currentRedactor   = NewRedactor(DefaultSensitiveKeyPatterns, nil, nil)
+	currentRedactorMx sync.RWMutex
+)
+
+// SetRedactor replaces the redactor which is used in dumps of all values providers,
+// use SetRedactor(NewUnmaskedRedactor()) to output sensitive values, it's safe to call it concurrently with dumps
+func SetRedactor(r *Redactor) {
+	currentRedactorMx.Lock()
+	defer currentRedactorMx.Unlock()
+
+	currentRedactor = r
+}
+
+// CurrentRedactor gives the redactor which is used in dumps of all values providers
+func CurrentRedactor() *Redactor {
+	currentRedactorMx.RLock()
+	defer currentRedactorMx.RUnlock()
+
+	return currentRedactor
+}
+
+// Redactor masks values of options which names match sensitive key patterns or are listed as sensitive keys
+type Redactor struct {
+	patterns      []string
+	sensitiveKeys map[string]bool
+	masker        Masker
+}
+
+// NewRedactor creates a redactor with case insensitive patterns in the path.Match format e.g. *_PASSWORD,
+// sensitiveKeys are case insensitive option names, DefaultMasker is used if masker is nil
+func NewRedactor(patterns, sensitiveKeys []string, masker Masker) *Redactor {
+	if masker == nil {
+		masker = DefaultMasker
+	}
+
+	r := &Redactor{
+		patterns:      make([]string, 0, len(patterns)),
+		sensitiveKeys: map[string]bool{},
+		masker:        masker,
+	}
+
+	for _, pattern := range patterns {
+		r.patterns = append(r.patterns, strings.ToUpper(pattern))
+	}
+
+	for _, key := range sensitiveKeys {
+		r.sensitiveKeys[strings.ToUpper(key)] = true
+	}
+
+	return r
+}
+
+// NewUnmaskedRedactor creates a redactor which outputs all values as they are
+func NewUnmaskedRedactor() *Redactor {
+	return NewRedactor(nil, nil, nil)
+}
+
+// IsSensitive tells if the value of the option should be masked
+func (r *Redactor) IsSensitive(key string) bool {
+	if r == nil {
+		return false
+	}
+
+	upperKey := strings.ToUpper(key)
+	if r.sensitiveKeys[upperKey] {
+		return true
+	}
+
+	for _, pattern := range r.patterns {
+		if isMatched, err := path.Match(pattern, upperKey); err == nil && isMatched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RedactValue masks val if key is sensitive, values of nested objects and lists are checked by their dotted keys
+func (r *Redactor) RedactValue(key string, val interface{}) interface{} {
+	if r == nil {
+		return val
+	}
+
+	if r.IsSensitive(key) {
+		return r.masker(key, val)
+	}
+
+	switch typedVal := val.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(typedVal))
+		for k, v := range typedVal {
+			res[k] = r.RedactValue(key+KeySeparator+k, v)
+		}
+		return res
+	case map[string]string:
+		res := make(map[string]interface{}, len(typedVal))
+		for k, v := range typedVal {
+			res[k] = r.RedactValue(key+KeySeparator+k, v)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(typedVal))
+		for i, v := range typedVal {
+			res[i] = r.RedactValue(key+KeySeparator+strconv.Itoa(i), v)
+		}
+		return res
+	default:
+		return val
+	}
+}
+
+// Redact gives a copy of kvs with masked sensitive values
+func (r *Redactor) Redact(kvs map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(kvs))
+	for k, v := range kvs {
+		res[k] = r.RedactValue(k, v)
+	}
+
+	return res
+}
+
+// RedactEnvironment masks sensitive values in the KEY=value list as it's given by os.Environ
+func (r *Redactor) RedactEnvironment(envs []string) []string {
+	res := make([]string, 0, len(envs))
+	for _, env := range envs {
+		envPair := strings.SplitN(env, "=", 2)
+		if len(envPair) < 2 || !r.IsSensitive(envPair[0]) {
+			res = append(res, env)
+			continue
+		}
+
+		res = append(res, envPair[0]+"="+fmt.Sprint(r.masker(envPair[0], envPair[1])))
+	}
+
+	return res
+}
@@ -0,0 +1,161 @@
+package options
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactorIsSensitive(t *testing.T) {
+	r := NewRedactor(DefaultSensitiveKeyPatterns, []string{"dsn"}, nil)
+
+	sensitiveKeys := []string{"DB_PASSWORD", "db.password", "CLIENT_SECRET", "secretKey", "GITHUB_TOKEN", "stripe_api_key", "DSN"}
+	for _, key := range sensitiveKeys {
+		assert.True(t, r.IsSensitive(key), key)
+	}
+
+	publicKeys := []string{"DB_HOST", "port", "dsn_timeout", ""}
+	for _, key := range publicKeys {
+		assert.False(t, r.IsSensitive(key), key)
+	}
+
+	var nilRedactor *Redactor
+	assert.False(t, nilRedactor.IsSensitive("DB_PASSWORD"))
+	assert.Equal(t, "pass", nilRedactor.RedactValue("DB_PASSWORD", "pass"))
+}
+
+func TestRedactorRedact(t *testing.T) {
+	r := NewRedactor([]string{"*_PASSWORD", "*.PASSWORD"}, []string{"servers.1.key"}, nil)
+
+	actualKvs := r.Redact(map[string]interface{}{
+		"DB_PASSWORD":    "pass",
+		"EMPTY_PASSWORD": "",
+		"NIL_PASSWORD":   nil,
+		"DB_HOST":        "localhost",
+		"db": map[string]interface{}{
+			"password": "pass",
+			"user":     "admin",
+		},
+		"labels":  map[string]string{"password": "pass"},
+		"servers": []interface{}{map[string]interface{}{"key": "one"}, map[string]interface{}{"key": "two"}},
+	})
+
+	assert.Equal(
+		t,
+		map[string]interface{}{
+			"DB_PASSWORD":    MaskedValue,
+			"EMPTY_PASSWORD": "",
+			"NIL_PASSWORD":   nil,
+			"DB_HOST":        "localhost",
+			"db": map[string]interface{}{
+				"password": MaskedValue,
+				"user":     "admin",
+			},
+			"labels":  map[string]interface{}{"password": MaskedValue},
+			"servers": []interface{}{map[string]interface{}{"key": "one"}, map[string]interface{}{"key": MaskedValue}},
+		},
+		actualKvs,
+	)
+
+	customRedactor := NewRedactor(DefaultSensitiveKeyPatterns, nil, func(key string, val interface{}) interface{} {
+		valStr := val.(string)
+		return valStr[:1] + "***"
+	})
+	assert.Equal(t, map[string]interface{}{"TOKEN": "a***"}, customRedactor.Redact(map[string]interface{}{"TOKEN": "abcdef"}))
+
+	assert.Equal(
+		t,
+		[]string{"DB_PASSWORD=" + MaskedValue, "DB_HOST=localhost", "BROKEN"},
+		r.RedactEnvironment([]string{"DB_PASSWORD=pass", "DB_HOST=localhost", "BROKEN"}),
+	)
+}
+
+func TestDumpsAreRedactedByDefault(t *testing.T) {
+	err := os.Setenv("SOME_REDACTED_PASSWORD", "envPass")
+	assert.NoError(t, err)
+	defer func() {
+		err = os.Unsetenv("SOME_REDACTED_PASSWORD")
+		if err != nil {
+			log.Println(err.Error())
+		}
+	}()
+
+	jvp, err := NewJSONValuesProvider(strings.NewReader(`{"db":{"password":"jsonPass","host":"localhost"}}`))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	mvp := NewMapValuesProvider(map[string]interface{}{"API_TOKEN": "mapToken"})
+	pb := New(NewValuesProviderComposite(mvp, jvp, &EnvValuesProvider{}))
+
+	dumps := map[string]func(b *bytes.Buffer) error{
+		"map": func(b *bytes.Buffer) error {
+			return mvp.Dump(b)
+		},
+		"json": func(b *bytes.Buffer) error {
+			return jvp.Dump(b)
+		},
+		"env": func(b *bytes.Buffer) error {
+			return (&EnvValuesProvider{}).Dump(b)
+		},
+		"composite": func(b *bytes.Buffer) error {
+			return pb.BaseValuesProvider.Dump(b)
+		},
+		"bag": func(b *bytes.Buffer) error {
+			return pb.Dump(b)
+		},
+		"sources": func(b *bytes.Buffer) error {
+			return pb.DumpWithSources(b)
+		},
+	}
+
+	for name, dump := range dumps {
+		b := &bytes.Buffer{}
+		err = dump(b)
+		assert.NoError(t, err, name)
+		assert.NotContains(t, b.String(), "envPass", name)
+		assert.NotContains(t, b.String(), "jsonPass", name)
+		assert.NotContains(t, b.String(), "mapToken", name)
+	}
+
+	assert.Equal(t, MaskedValue, pb.ToKeyValues()["API_TOKEN"])
+	assert.Equal(t, "mapToken", pb.BaseValuesProvider.ToKeyValues()["API_TOKEN"])
+	assert.Equal(t, "mapToken", pb.ReadString("API_TOKEN", ""))
+
+	SetRedactor(NewUnmaskedRedactor())
+	defer SetRedactor(NewRedactor(DefaultSensitiveKeyPatterns, nil, nil))
+
+	b := &bytes.Buffer{}
+	err = pb.Dump(b)
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), "envPass")
+	assert.Contains(t, b.String(), "jsonPass")
+	assert.Contains(t, b.String(), "mapToken")
+}
+
+func TestSetRedactorConcurrentlyWithDumps(t *testing.T) {
+	defer SetRedactor(NewRedactor(DefaultSensitiveKeyPatterns, nil, nil))
+
+	mvp := NewMapValuesProvider(map[string]interface{}{"API_TOKEN": "mapToken"})
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			SetRedactor(NewRedactor(DefaultSensitiveKeyPatterns, nil, nil))
+		}()
+		go func() {
+			defer wg.Done()
+			b := &bytes.Buffer{}
+			assert.NoError(t, mvp.Dump(b))
+			assert.NotContains(t, b.String(), "mapToken")
+		}()
+	}
+	wg.Wait()
+}
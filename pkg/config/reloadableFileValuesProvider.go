@@ -0,0 +1,254 @@
+package options
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/breathbath/go_utils/v3/pkg/conv"
+	io2 "github.com/breathbath/go_utils/v3/pkg/io"
+)
+
+// ReloadLoggingTopic is used as topic for outputs of failed config reloads
+const ReloadLoggingTopic = "config_reload"
+
+// FileParser converts file contents to option values
+type FileParser func(data []byte) (map[string]interface{}, error)
+
+// ParseJSONFile is a FileParser for json files with the same conversion rules as in NewJSONValuesProvider
+func ParseJSONFile(data []byte) (map[string]interface{}, error) {
+	return parseJSONValues(data)
+}
+
+// ParseYAMLFile is a FileParser for yaml files with the same conversion rules as in NewYAMLValuesProvider
+func ParseYAMLFile(data []byte) (map[string]interface{}, error) {
+	return parseYAMLValues(data)
+}
+
+// ParseDotEnvFile is a FileParser for dotenv files with the same conversion rules as in NewDotEnvValuesProvider
+func ParseDotEnvFile(data []byte) (map[string]interface{}, error) {
+	return parseDotEnv(string(data))
+}
+
+// FileParserByExtension gives a parser for .json, .yaml, .yml and .env (.env, .env.local, prod.env) files
+func FileParserByExtension(filePath string) (FileParser, error) {
+	baseName := filepath.Base(filePath)
+	if baseName == ".env" || strings.HasPrefix(baseName, ".env.") {
+		return ParseDotEnvFile, nil
+	}
+
+	switch strings.ToLower(filepath.Ext(baseName)) {
+	case ".json":
+		return ParseJSONFile, nil
+	case ".yaml", ".yml":
+		return ParseYAMLFile, nil
+	case ".env":
+		return ParseDotEnvFile, nil
+	default:
+		return nil, fmt.Errorf("unsupported config file format of %s", filePath)
+	}
+}
+
+// ReloadableFileValuesProvider gives values from a file and reloads them when the file is changed
+type ReloadableFileValuesProvider struct {
+	filePath    string
+	parser      FileParser
+	reloadMx    sync.Mutex
+	mx          sync.RWMutex
+	vals        *MapValuesProvider
+	keysMode    KeysMode
+	modTime     time.Time
+	size        int64
+	hash        string
+	subscribers []func(diff *Diff)
+}
+
+// NewReloadableFileValuesProvider reads values from filePath with the parser, if parser is nil,
+// it's detected by the file extension with FileParserByExtension
+func NewReloadableFileValuesProvider(filePath string, parser FileParser) (*ReloadableFileValuesProvider, error) {
+	if parser == nil {
+		var err error
+		parser, err = FileParserByExtension(filePath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rfvp := &ReloadableFileValuesProvider{
+		filePath: filePath,
+		parser:   parser,
+		vals:     NewMapValuesProvider(map[string]interface{}{}),
+	}
+
+	_, err := rfvp.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return rfvp, nil
+}
+
+// OnChange registers a callback which is called with the sorted flat names of added, removed or changed options
+// after each successful reload
+func (rfvp *ReloadableFileValuesProvider) OnChange(callback func(changedKeys []string)) {
+	rfvp.OnDiff(func(diff *Diff) {
+		callback(diff.Keys())
+	})
+}
+
+// OnDiff registers a callback which is called with old and new values of changed options after each successful reload,
+// use Diff.WriteText or Diff.WriteJSON to log the changes with masked sensitive values
+func (rfvp *ReloadableFileValuesProvider) OnDiff(callback func(diff *Diff)) {
+	rfvp.mx.Lock()
+	defer rfvp.mx.Unlock()
+
+	rfvp.subscribers = append(rfvp.subscribers, callback)
+}
+
+// Watch checks the file every interval in background until ctx is done, failed reloads are reported
+// with io.OutputError and the last successfully loaded values are kept, the returned channel is closed
+// when watching is stopped
+func (rfvp *ReloadableFileValuesProvider) Watch(ctx context.Context, interval time.Duration) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_, err := rfvp.Reload()
+				if err != nil {
+					io2.OutputError(err, ReloadLoggingTopic, "failed to reload config file %s", rfvp.filePath)
+				}
+			}
+		}
+	}()
+
+	return done
+}
+
+// Reload reads the file if its modification time or size was changed and swaps the values if the file hash differs,
+// it gives the names of changed options, on failure the current values are kept
+func (rfvp *ReloadableFileValuesProvider) Reload() (changedKeys []string, err error) {
+	rfvp.reloadMx.Lock()
+	defer rfvp.reloadMx.Unlock()
+
+	fileInfo, err := os.Stat(rfvp.filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	rfvp.mx.RLock()
+	isModified := !fileInfo.ModTime().Equal(rfvp.modTime) || fileInfo.Size() != rfvp.size
+	rfvp.mx.RUnlock()
+	if !isModified {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(rfvp.filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	hashSum := sha256.Sum256(data)
+	hash := hex.EncodeToString(hashSum[:])
+
+	rfvp.mx.Lock()
+	// the file state is remembered also for the failed parsing to report each broken file version only once
+	rfvp.modTime = fileInfo.ModTime()
+	rfvp.size = fileInfo.Size()
+	isChanged := hash != rfvp.hash
+	rfvp.mx.Unlock()
+
+	if !isChanged {
+		return nil, nil
+	}
+
+	params, err := rfvp.parser(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", rfvp.filePath, err)
+	}
+	newVals := &MapValuesProvider{
+		parameters: conv.ConvertMapToSyncMap(params),
+	}
+
+	rfvp.mx.Lock()
+
+	oldVals := rfvp.vals
+	newVals.keysMode = rfvp.keysMode
+	rfvp.vals = newVals
+	rfvp.hash = hash
+	subscribers := make([]func(diff *Diff), len(rfvp.subscribers))
+	copy(subscribers, rfvp.subscribers)
+	rfvp.mx.Unlock()
+
+	diff := DiffKeyValues(
+		conv.ConvertSyncMapToMap(oldVals.parameters),
+		conv.ConvertSyncMapToMap(newVals.parameters),
+	)
+	if diff.IsEmpty() {
+		return nil, nil
+	}
+
+	for _, subscriber := range subscribers {
+		subscriber(diff)
+	}
+
+	return diff.Keys(), nil
+}
+
+// FilePath gives the path of the watched file
+func (rfvp *ReloadableFileValuesProvider) FilePath() string {
+	return rfvp.filePath
+}
+
+// SetKeysMode defines if ToKeyValues and Dump should give nested values as a tree or as flat dotted keys
+func (rfvp *ReloadableFileValuesProvider) SetKeysMode(mode KeysMode) {
+	rfvp.mx.Lock()
+	defer rfvp.mx.Unlock()
+
+	rfvp.keysMode = mode
+	rfvp.vals = &MapValuesProvider{
+		parameters: rfvp.vals.parameters,
+		keysMode:   mode,
+	}
+}
+
+func (rfvp *ReloadableFileValuesProvider) currentValues() *MapValuesProvider {
+	rfvp.mx.RLock()
+	defer rfvp.mx.RUnlock()
+
+	return rfvp.vals
+}
+
+func (rfvp *ReloadableFileValuesProvider) Read(name string) (val interface{}, found bool) {
+	return rfvp.currentValues().Read(name)
+}
+
+func (rfvp *ReloadableFileValuesProvider) Dump(w io.Writer) (err error) {
+	return rfvp.currentValues().Dump(w)
+}
+
+// DumpFormatted outputs values in the given format, see DumpFormatted
+func (rfvp *ReloadableFileValuesProvider) DumpFormatted(w io.Writer, format DumpFormat) error {
+	return DumpFormatted(rfvp, w, format)
+}
+
+func (rfvp *ReloadableFileValuesProvider) Label() string {
+	return "file:" + rfvp.filePath
+}
+
+func (rfvp *ReloadableFileValuesProvider) ToKeyValues() map[string]interface{} {
+	return rfvp.currentValues().ToKeyValues()
+}
@@ -0,0 +1,179 @@
+package options
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	io2 "github.com/breathbath/go_utils/v3/pkg/io"
+)
+
+func writeConfigFile(t *testing.T, filePath, content string, modTime time.Time) {
+	err := os.WriteFile(filePath, []byte(content), 0600)
+	require.NoError(t, err)
+
+	err = os.Chtimes(filePath, modTime, modTime)
+	require.NoError(t, err)
+}
+
+func TestFileParserByExtension(t *testing.T) {
+	testCases := []struct {
+		filePath    string
+		expectedKvs map[string]interface{}
+		content     string
+	}{
+		{filePath: "config.json", content: `{"a":1}`, expectedKvs: map[string]interface{}{"a": 1}},
+		{filePath: "/etc/config.YAML", content: "a: 1", expectedKvs: map[string]interface{}{"a": 1}},
+		{filePath: "config.yml", content: "a: 1", expectedKvs: map[string]interface{}{"a": 1}},
+		{filePath: ".env", content: "A=1", expectedKvs: map[string]interface{}{"A": "1"}},
+		{filePath: "app/.env.local", content: "A=1", expectedKvs: map[string]interface{}{"A": "1"}},
+		{filePath: "prod.env", content: "A=1", expectedKvs: map[string]interface{}{"A": "1"}},
+	}
+
+	for _, testCase := range testCases {
+		parser, err := FileParserByExtension(testCase.filePath)
+		assert.NoError(t, err, testCase.filePath)
+		if err != nil {
+			continue
+		}
+
+		kvs, err := parser([]byte(testCase.content))
+		assert.NoError(t, err, testCase.filePath)
+		assert.Equal(t, testCase.expectedKvs, kvs, testCase.filePath)
+	}
+
+	_, err := FileParserByExtension("config.xml")
+	assert.EqualError(t, err, "unsupported config file format of config.xml")
+}
+
+func TestReloadableFileValuesProviderReload(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "config.json")
+	startTime := time.Now().Add(-time.Hour)
+	writeConfigFile(t, filePath, `{"color":"red","size":1,"db":{"host":"a"}}`, startTime)
+
+	rfvp, err := NewReloadableFileValuesProvider(filePath, nil)
+	require.NoError(t, err)
+
+	pb := New(rfvp)
+	assert.Equal(t, "red", pb.ReadString("color", ""))
+	assert.Equal(t, "a", pb.ReadString("db.host", ""))
+
+	notifiedKeys := [][]string{}
+	rfvp.OnChange(func(changedKeys []string) {
+		notifiedKeys = append(notifiedKeys, changedKeys)
+	})
+
+	changedKeys, err := rfvp.Reload()
+	assert.NoError(t, err)
+	assert.Nil(t, changedKeys)
+
+	writeConfigFile(t, filePath, `{"color":"red","size":1,"db":{"host":"a"}}`, startTime.Add(time.Minute))
+	changedKeys, err = rfvp.Reload()
+	assert.NoError(t, err)
+	assert.Nil(t, changedKeys)
+
+	writeConfigFile(t, filePath, `{"color":"blue","db":{"host":"b"},"weight":3}`, startTime.Add(time.Minute*2))
+	changedKeys, err = rfvp.Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"color", "db.host", "size", "weight"}, changedKeys)
+	assert.Equal(t, [][]string{{"color", "db.host", "size", "weight"}}, notifiedKeys)
+
+	assert.Equal(t, "blue", pb.ReadString("color", ""))
+	assert.Equal(t, "b", pb.ReadString("db.host", ""))
+	assert.Equal(t, 0, pb.ReadInt("size", 0))
+
+	writeConfigFile(t, filePath, `{"color":`, startTime.Add(time.Minute*3))
+	_, err = rfvp.Reload()
+	assert.EqualError(t, err, "failed to parse "+filePath+": unexpected EOF")
+	assert.Equal(t, "blue", pb.ReadString("color", ""))
+	assert.Len(t, notifiedKeys, 1)
+
+	rfvp.SetKeysMode(KeysModeFlat)
+	assert.Equal(t, map[string]interface{}{"color": "blue", "db.host": "b", "weight": 3}, rfvp.ToKeyValues())
+
+	b := &bytes.Buffer{}
+	err = rfvp.Dump(b)
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), `"db.host":"b"`)
+}
+
+func TestReloadableFileValuesProviderWatch(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "config.yaml")
+	startTime := time.Now().Add(-time.Hour)
+	writeConfigFile(t, filePath, "color: red", startTime)
+
+	rfvp, err := NewReloadableFileValuesProvider(filePath, nil)
+	require.NoError(t, err)
+
+	logger := &channelLoggerMock{messages: make(chan string, 10)}
+	io2.SetLogger(logger)
+	defer io2.SetLogger(io2.DefaultLogger{})
+
+	changes := make(chan []string, 1)
+	rfvp.OnChange(func(changedKeys []string) {
+		changes <- changedKeys
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := rfvp.Watch(ctx, time.Millisecond*10)
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	writeConfigFile(t, filePath, "color: blue", startTime.Add(time.Minute))
+
+	select {
+	case changedKeys := <-changes:
+		assert.Equal(t, []string{"color"}, changedKeys)
+	case <-time.After(time.Second * 5):
+		assert.Fail(t, "no change notification received")
+	}
+	assert.Equal(t, "blue", New(rfvp).ReadString("color", ""))
+
+	writeConfigFile(t, filePath, "color: [", startTime.Add(time.Minute*2))
+
+	select {
+	case msg := <-logger.messages:
+		assert.Contains(t, msg, "failed to reload config file "+filePath)
+	case <-time.After(time.Second * 5):
+		assert.Fail(t, "no error output received")
+	}
+	assert.Equal(t, "blue", New(rfvp).ReadString("color", ""))
+}
+
+type channelLoggerMock struct {
+	messages chan string
+}
+
+func (clm *channelLoggerMock) OutputMessageType(messageType, topic, msg string, args ...interface{}) {
+	clm.messages <- fmt.Sprintf("[%s] %s [%s]", messageType, fmt.Sprintf(msg, args...), topic)
+}
+
+func TestReloadableFileValuesProviderFailures(t *testing.T) {
+	_, err := NewReloadableFileValuesProvider("config.txt", nil)
+	assert.EqualError(t, err, "unsupported config file format of config.txt")
+
+	_, err = NewReloadableFileValuesProvider(filepath.Join(t.TempDir(), "missing.json"), nil)
+	assert.Error(t, err)
+
+	yamlPath := filepath.Join(t.TempDir(), "config.yml")
+	startTime := time.Now().Add(-time.Hour)
+	writeConfigFile(t, yamlPath, "color: red\n", startTime)
+	rfvp, err := NewReloadableFileValuesProvider(yamlPath, nil)
+	require.NoError(t, err)
+
+	writeConfigFile(t, yamlPath, "0: [:!00 \xef", startTime.Add(time.Minute))
+	assert.NotPanics(t, func() {
+		_, err = rfvp.Reload()
+	})
+	assert.Error(t, err)
+	assert.Equal(t, "red", New(rfvp).ReadString("color", ""))
+}
@@ -0,0 +1,273 @@
+package options
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	errs2 "github.com/breathbath/go_utils/v3/pkg/errs"
+)
+
+// OptionType defines how an option value is parsed during the schema validation
+type OptionType string
+
+const (
+	TypeString   OptionType = "string"
+	TypeInt      OptionType = "int"
+	TypeUint     OptionType = "uint"
+	TypeFloat    OptionType = "float"
+	TypeBool     OptionType = "bool"
+	TypeDuration OptionType = "duration"
+	TypeURL      OptionType = "url"
+	TypeEnum     OptionType = "enum"
+	TypeRegex    OptionType = "regex"
+)
+
+var boolValues = map[string]bool{
+	"true":  true,
+	"false": true,
+	"1":     true,
+	"0":     true,
+}
+
+// Limit is a helper to set Min and Max constraints of an OptionSpec e.g. Min: Limit(1)
+func Limit(val float64) *float64 {
+	return &val
+}
+
+/*
+OptionSpec describes one option of a Schema:
+Min and Max limit numbers, durations in Unit (seconds if not set) and lengths of strings, urls, enums and regex values,
+OneOf limits values of any type to the listed ones compared as strings, for TypeEnum OneOf gives the allowed values,
+Pattern is a regular expression which string, url and regex values should match,
+Default is shown in the reference and is not validated
+*/
+type OptionSpec struct {
+	Key         string
+	Type        OptionType
+	Description string
+	Default     interface{}
+	Required    bool
+	Min         *float64
+	Max         *float64
+	OneOf       []string
+	Pattern     string
+	Unit        time.Duration
+}
+
+// Schema is a list of option specs which can validate a ParameterBag and render the options reference
+type Schema struct {
+	Options []OptionSpec
+}
+
+// NewSchema creates a schema from option specs
+func NewSchema(options ...OptionSpec) *Schema {
+	return &Schema{
+		Options: options,
+	}
+}
+
+// Validate checks all options of the schema against the bag values and returns all violations as one error
+func (p *ParameterBag) Validate(schema *Schema) error {
+	errs := errs2.NewErrorContainer()
+	for i := range schema.Options {
+		errs.AddError(p.validateOption(&schema.Options[i]))
+	}
+
+	return errs.Result(" ")
+}
+
+func (p *ParameterBag) validateOption(spec *OptionSpec) error {
+	valI, found, err := p.readValue(spec.Key)
+	optionType := spec.Type
+	if optionType == "" {
+		optionType = TypeString
+	}
+	p.recordUsage(spec.Key, string(optionType), spec.Default, spec.Required, found && err == nil)
+
+	if err != nil {
+		return fmt.Errorf("invalid option %s: %v", spec.Key, err)
+	}
+
+	if !found || valI == nil || valI == "" {
+		if spec.Required {
+			return fmt.Errorf("required option %s is empty", spec.Key)
+		}
+		return nil
+	}
+
+	size, err := p.untracked().validateType(spec, valI)
+	if err != nil {
+		return fmt.Errorf("invalid option %s: %v", spec.Key, err)
+	}
+
+	valStr := fmt.Sprint(valI)
+	if len(spec.OneOf) > 0 && !isOneOf(valStr, spec.OneOf) {
+		return fmt.Errorf("invalid option %s: %s is not one of %s", spec.Key, valStr, strings.Join(spec.OneOf, ", "))
+	}
+
+	if spec.Min != nil && size < *spec.Min {
+		return fmt.Errorf("invalid option %s: %s is less than %v", spec.Key, valStr, *spec.Min)
+	}
+
+	if spec.Max != nil && size > *spec.Max {
+		return fmt.Errorf("invalid option %s: %s is greater than %v", spec.Key, valStr, *spec.Max)
+	}
+
+	return nil
+}
+
+// validateType checks if the value can be converted to the spec type and gives its size to check Min and Max limits
+func (p *ParameterBag) validateType(spec *OptionSpec, valI interface{}) (size float64, err error) {
+	valStr := fmt.Sprint(valI)
+
+	switch spec.Type {
+	case TypeInt:
+		intVal, e := p.ReadRequiredInt64(spec.Key)
+		return float64(intVal), e
+	case TypeUint:
+		uintVal, e := p.ReadRequiredUint(spec.Key)
+		return float64(uintVal), e
+	case TypeFloat:
+		return p.ReadRequiredFloat64(spec.Key)
+	case TypeBool:
+		if _, isBool := valI.(bool); !isBool && !boolValues[strings.ToLower(strings.TrimSpace(valStr))] {
+			return 0, fmt.Errorf("cannot convert %v to bool", valI)
+		}
+		return 0, nil
+	case TypeDuration:
+		unit := spec.Unit
+		if unit == 0 {
+			unit = time.Second
+		}
+		dur, e := p.ReadRequiredDuration(spec.Key, unit)
+		return float64(dur) / float64(unit), e
+	case TypeURL:
+		if _, e := convertToURL(valI); e != nil {
+			return 0, e
+		}
+	case TypeEnum:
+		if !isOneOf(valStr, spec.OneOf) {
+			return 0, fmt.Errorf("%s is not one of %s", valStr, strings.Join(spec.OneOf, ", "))
+		}
+	case TypeRegex:
+		if _, e := regexp.Compile(valStr); e != nil {
+			return 0, fmt.Errorf("%s is not a valid regular expression: %v", valStr, e)
+		}
+	case TypeString, "":
+	default:
+		return 0, fmt.Errorf("unknown option type %s", spec.Type)
+	}
+
+	if spec.Pattern != "" && spec.Type != TypeEnum {
+		isMatched, e := regexp.MatchString(spec.Pattern, valStr)
+		if e != nil {
+			return 0, fmt.Errorf("invalid pattern %s: %v", spec.Pattern, e)
+		}
+		if !isMatched {
+			return 0, fmt.Errorf("%s doesn't match %s", valStr, spec.Pattern)
+		}
+	}
+
+	return float64(len([]rune(valStr))), nil
+}
+
+func isOneOf(val string, allowedVals []string) bool {
+	for _, allowedVal := range allowedVals {
+		if val == allowedVal {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+Reference outputs an aligned table of all options with their types, defaults, constraints and descriptions e.g.
+
+	OPTION   TYPE  REQUIRED  DEFAULT  CONSTRAINTS       DESCRIPTION
+	db.port  int   yes       5432     min 1, max 65535  database port
+*/
+func (s *Schema) Reference(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, err := fmt.Fprintln(tw, "OPTION\tTYPE\tREQUIRED\tDEFAULT\tCONSTRAINTS\tDESCRIPTION")
+	if err != nil {
+		return err
+	}
+
+	for i := range s.Options {
+		spec := &s.Options[i]
+
+		optionType := spec.Type
+		if optionType == "" {
+			optionType = TypeString
+		}
+
+		required := "no"
+		if spec.Required {
+			required = "yes"
+		}
+
+		defaultVal := "-"
+		if spec.Default != nil {
+			defaultVal = fmt.Sprint(spec.Default)
+		}
+
+		description := spec.Description
+		if description == "" {
+			description = "-"
+		}
+
+		_, err = fmt.Fprintf(
+			tw,
+			"%s\t%s\t%s\t%s\t%s\t%s\n",
+			spec.Key,
+			optionType,
+			required,
+			defaultVal,
+			spec.constraints(),
+			description,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
+
+func (spec *OptionSpec) constraints() string {
+	constraints := []string{}
+	if spec.Min != nil {
+		constraints = append(constraints, fmt.Sprintf("min %v", *spec.Min))
+	}
+
+	if spec.Max != nil {
+		constraints = append(constraints, fmt.Sprintf("max %v", *spec.Max))
+	}
+
+	if len(spec.OneOf) > 0 {
+		constraints = append(constraints, "one of "+strings.Join(spec.OneOf, "|"))
+	}
+
+	if spec.Pattern != "" {
+		constraints = append(constraints, "pattern "+spec.Pattern)
+	}
+
+	if spec.Type == TypeDuration {
+		unit := spec.Unit
+		if unit == 0 {
+			unit = time.Second
+		}
+		constraints = append(constraints, "unit "+unit.String())
+	}
+
+	if len(constraints) == 0 {
+		return "-"
+	}
+
+	return strings.Join(constraints, ", ")
+}
@@ -0,0 +1,104 @@
+package options
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func buildSchemaMock() *Schema {
+	return NewSchema(
+		OptionSpec{Key: "db.host", Description: "database host", Default: "localhost", Pattern: `^[a-z.]+$`},
+		OptionSpec{Key: "db.port", Type: TypeInt, Required: true, Min: Limit(1), Max: Limit(65535), Default: 5432},
+		OptionSpec{Key: "workers", Type: TypeUint, Max: Limit(10)},
+		OptionSpec{Key: "ratio", Type: TypeFloat, Min: Limit(0), Max: Limit(1)},
+		OptionSpec{Key: "debug", Type: TypeBool},
+		OptionSpec{Key: "timeout", Type: TypeDuration, Unit: time.Millisecond, Max: Limit(5000)},
+		OptionSpec{Key: "api_url", Type: TypeURL, Required: true},
+		OptionSpec{Key: "log_level", Type: TypeEnum, OneOf: []string{"debug", "info", "error"}, Default: "info"},
+		OptionSpec{Key: "filter", Type: TypeRegex},
+		OptionSpec{Key: "mode", OneOf: []string{"fast", "slow"}},
+		OptionSpec{Key: "name", Min: Limit(3)},
+	)
+}
+
+func TestParameterBagValidate(t *testing.T) {
+	validBag := New(NewMapValuesProvider(map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "db.local",
+			"port": "5432",
+		},
+		"workers":   uint(5),
+		"ratio":     0.5,
+		"debug":     "1",
+		"timeout":   "300",
+		"api_url":   "https://api.local/v1",
+		"log_level": "debug",
+		"filter":    "^a.*",
+		"mode":      "fast",
+		"name":      "app",
+	}))
+
+	err := validBag.Validate(buildSchemaMock())
+	assert.NoError(t, err)
+
+	invalidBag := New(NewMapValuesProvider(map[string]interface{}{
+		"db.host":   "DB_HOST",
+		"db.port":   70000,
+		"workers":   -1,
+		"ratio":     "high",
+		"debug":     "maybe",
+		"timeout":   time.Second * 10,
+		"api_url":   "/v1",
+		"log_level": "trace",
+		"filter":    "[a",
+		"mode":      "medium",
+		"name":      "ab",
+	}))
+
+	err = invalidBag.Validate(buildSchemaMock())
+	assert.EqualError(
+		t,
+		err,
+		"invalid option db.host: DB_HOST doesn't match ^[a-z.]+$ "+
+			"invalid option db.port: 70000 is greater than 65535 "+
+			"invalid option workers: cannot convert -1 to uint "+
+			"invalid option ratio: cannot convert high to float "+
+			"invalid option debug: cannot convert maybe to bool "+
+			"invalid option timeout: 10s is greater than 5000 "+
+			"invalid option api_url: /v1 is not a valid absolute url "+
+			"invalid option log_level: trace is not one of debug, info, error "+
+			"invalid option filter: [a is not a valid regular expression: error parsing regexp: missing closing ]: `[a` "+
+			"invalid option mode: medium is not one of fast, slow "+
+			"invalid option name: ab is less than 3",
+	)
+
+	err = New(nil).Validate(buildSchemaMock())
+	assert.EqualError(t, err, "required option db.port is empty required option api_url is empty")
+
+	err = New(NewMapValuesProvider(map[string]interface{}{"key": "val"})).Validate(NewSchema(OptionSpec{Key: "key", Type: "date"}))
+	assert.EqualError(t, err, "invalid option key: unknown option type date")
+}
+
+func TestSchemaReference(t *testing.T) {
+	b := &bytes.Buffer{}
+	err := buildSchemaMock().Reference(b)
+	assert.NoError(t, err)
+
+	expectedOutput := `OPTION     TYPE      REQUIRED  DEFAULT    CONSTRAINTS              DESCRIPTION
+db.host    string    no        localhost  pattern ^[a-z.]+$        database host
+db.port    int       yes       5432       min 1, max 65535         -
+workers    uint      no        -          max 10                   -
+ratio      float     no        -          min 0, max 1             -
+debug      bool      no        -          -                        -
+timeout    duration  no        -          max 5000, unit 1ms       -
+api_url    url       yes       -          -                        -
+log_level  enum      no        info       one of debug|info|error  -
+filter     regex     no        -          -                        -
+mode       string    no        -          one of fast|slow         -
+name       string    no        -          min 3                    -
+`
+	assert.Equal(t, expectedOutput, b.String())
+}
@@ -0,0 +1,264 @@
+package options
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	io2 "github.com/breathbath/go_utils/v3/pkg/io"
+	"github.com/breathbath/go_utils/v3/pkg/types"
+)
+
+// FileRefSuffix marks options which contain a path to the file with the option value e.g. DB_PASSWORD_FILE
+const FileRefSuffix = "_FILE"
+
+// readValueFile reads the option value from filePath without trailing line breaks
+func readValueFile(filePath string) (string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+/*
+SecretsDirValuesProvider gives values of files in a directory e.g. docker secrets in /run/secrets or a mounted
+kubernetes secret, the file name is the option name and the file contents without trailing line breaks is the value:
+
+	/run/secrets/db_password -> db_password
+
+if no file matches the name exactly, the lower case name is tried, so DB_PASSWORD is also read from db_password,
+files are read on each Read call, so rotated secrets are picked up without a restart
+*/
+type SecretsDirValuesProvider struct {
+	dir string
+}
+
+// NewSecretsDirValuesProvider creates a provider for the secrets directory or fails if dir is not a readable directory
+func NewSecretsDirValuesProvider(dir string) (*SecretsDirValuesProvider, error) {
+	stat, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if !stat.IsDir() {
+		return nil, fmt.Errorf("secrets path %s is not a directory", dir)
+	}
+
+	return &SecretsDirValuesProvider{dir: dir}, nil
+}
+
+// Dir gives the secrets directory
+func (sdvp *SecretsDirValuesProvider) Dir() string {
+	return sdvp.dir
+}
+
+func (sdvp *SecretsDirValuesProvider) Read(name string) (val interface{}, found bool) {
+	val, found, _ = sdvp.ReadWithError(name)
+	return
+}
+
+// ReadWithError same as Read but gives an error if the secret file exists but cannot be read
+func (sdvp *SecretsDirValuesProvider) ReadWithError(name string) (val interface{}, found bool, err error) {
+	if !isSecretFileName(name) {
+		return nil, false, nil
+	}
+
+	fileNames := []string{name}
+	if lowerName := strings.ToLower(name); lowerName != name {
+		fileNames = append(fileNames, lowerName)
+	}
+
+	for _, fileName := range fileNames {
+		filePath := filepath.Join(sdvp.dir, fileName)
+		if _, e := os.Lstat(filePath); os.IsNotExist(e) {
+			continue
+		}
+
+		if stat, e := os.Stat(filePath); e == nil && stat.IsDir() {
+			continue
+		}
+
+		valStr, e := readValueFile(filePath)
+		if e != nil {
+			return nil, false, fmt.Errorf("failed to read secret %s: %v", name, e)
+		}
+
+		return valStr, true, nil
+	}
+
+	return nil, false, nil
+}
+
+// isSecretFileName rejects names which could point outside of the secrets directory or to hidden files
+func isSecretFileName(name string) bool {
+	return name != "" && !strings.HasPrefix(name, ".") && !strings.ContainsAny(name, `/\`)
+}
+
+/*
+ToKeyValues gives values of all readable files of the directory wrapped in types.Secret, so they are masked in dumps
+and diffs even if the file names don't look sensitive, use Read or ReadWithError to get plain values,
+hidden files like kubernetes ..data are skipped, read errors are logged with io.OutputError
+*/
+func (sdvp *SecretsDirValuesProvider) ToKeyValues() map[string]interface{} {
+	res := map[string]interface{}{}
+	files, err := os.ReadDir(sdvp.dir)
+	if err != nil {
+		io2.OutputError(err, ReadLoggingTopic, "failed to read secrets directory %s", sdvp.dir)
+		return res
+	}
+
+	for _, file := range files {
+		if !isSecretFileName(file.Name()) {
+			continue
+		}
+
+		val, found, err := sdvp.ReadWithError(file.Name())
+		if err != nil {
+			io2.OutputError(err, ReadLoggingTopic, "secrets dir provider skips file %s", filepath.Join(sdvp.dir, file.Name()))
+			continue
+		}
+
+		if found {
+			res[file.Name()] = types.NewSecret(fmt.Sprint(val))
+		}
+	}
+
+	return res
+}
+
+// Dump outputs values of the secret files as json, all values are masked
+func (sdvp *SecretsDirValuesProvider) Dump(w io.Writer) (err error) {
+	data := CurrentRedactor().Redact(sdvp.ToKeyValues())
+	jsonEncoder := json.NewEncoder(w)
+	return jsonEncoder.Encode(data)
+}
+
+// DumpFormatted outputs values in the given format, see DumpFormatted
+func (sdvp *SecretsDirValuesProvider) DumpFormatted(w io.Writer, format DumpFormat) error {
+	return DumpFormatted(sdvp, w, format)
+}
+
+func (sdvp *SecretsDirValuesProvider) Label() string {
+	return "secrets:" + sdvp.dir
+}
+
+/*
+FileRefValuesProvider resolves the _FILE convention of docker images over the wrapped provider:
+if an option is not set, the value is read from the file which path is given in the option with the FileRefSuffix e.g.
+
+	DB_PASSWORD_FILE=/run/secrets/db_password -> DB_PASSWORD
+
+a directly set option has precedence over its file reference, a missing or unreadable file gives a read error
+*/
+type FileRefValuesProvider struct {
+	base ValuesProvider
+}
+
+// NewFileRefValuesProvider wraps vp to resolve _FILE references of its options
+func NewFileRefValuesProvider(vp ValuesProvider) *FileRefValuesProvider {
+	if vp == nil {
+		vp = &NullValuesProvider{}
+	}
+
+	return &FileRefValuesProvider{base: vp}
+}
+
+func (frvp *FileRefValuesProvider) Read(name string) (val interface{}, found bool) {
+	val, found, _ = frvp.ReadWithError(name)
+	return
+}
+
+// ReadWithError same as Read but gives an error if the referenced file cannot be read
+func (frvp *FileRefValuesProvider) ReadWithError(name string) (val interface{}, found bool, err error) {
+	val, found, err = ReadValue(frvp.base, name)
+	if err != nil || found {
+		return
+	}
+
+	refName := name + FileRefSuffix
+	filePath, found, err := ReadValue(frvp.base, refName)
+	if err != nil || !found {
+		return nil, false, err
+	}
+
+	valStr, err := readValueFile(fmt.Sprint(filePath))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read file of option %s: %v", refName, err)
+	}
+
+	return valStr, true, nil
+}
+
+// ToKeyValues gives values of the wrapped provider with resolved file references, values read from files are wrapped
+// in types.Secret, so they are masked in dumps and diffs, read errors are logged with io.OutputError
+func (frvp *FileRefValuesProvider) ToKeyValues() map[string]interface{} {
+	baseKvs := frvp.base.ToKeyValues()
+	res := make(map[string]interface{}, len(baseKvs))
+	for key, val := range baseKvs {
+		res[key] = val
+	}
+
+	for key := range baseKvs {
+		if !strings.HasSuffix(key, FileRefSuffix) {
+			continue
+		}
+
+		name := strings.TrimSuffix(key, FileRefSuffix)
+		if _, isSet := baseKvs[name]; isSet || name == "" {
+			continue
+		}
+
+		val, found, err := frvp.ReadWithError(name)
+		if err != nil {
+			io2.OutputError(err, ReadLoggingTopic, "file ref provider skips option %s of file %v", name, baseKvs[key])
+			continue
+		}
+
+		if found {
+			res[name] = types.NewSecret(fmt.Sprint(val))
+		}
+	}
+
+	return res
+}
+
+// Dump outputs values with resolved file references as json, sensitive values are masked by CurrentRedactor
+func (frvp *FileRefValuesProvider) Dump(w io.Writer) (err error) {
+	data := CurrentRedactor().Redact(frvp.ToKeyValues())
+	jsonEncoder := json.NewEncoder(w)
+	return jsonEncoder.Encode(data)
+}
+
+// DumpFormatted outputs values in the given format, see DumpFormatted
+func (frvp *FileRefValuesProvider) DumpFormatted(w io.Writer, format DumpFormat) error {
+	return DumpFormatted(frvp, w, format)
+}
+
+func (frvp *FileRefValuesProvider) Label() string {
+	return "file_ref:" + ProviderLabel(frvp.base)
+}
+
+func (frvp *FileRefValuesProvider) DecoratedProvider() ValuesProvider {
+	return frvp.base
+}
+
+// Decorate wraps vp to resolve _FILE references of its options
+func (frvp *FileRefValuesProvider) Decorate(vp ValuesProvider) ValuesProvider {
+	return NewFileRefValuesProvider(vp)
+}
+
+// KeyStyle gives the key style of the wrapped values provider
+func (frvp *FileRefValuesProvider) KeyStyle() KeyStyle {
+	return ProviderKeyStyle(frvp.base)
+}
+
+// HasStringValues tells if the wrapped values provider gives plain string values, see StringValuesProvider
+func (frvp *FileRefValuesProvider) HasStringValues() bool {
+	svp, ok := frvp.base.(StringValuesProvider)
+	return ok && svp.HasStringValues()
+}
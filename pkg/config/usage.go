@@ -0,0 +1,228 @@
+package options
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/breathbath/go_utils/v3/pkg/env"
+)
+
+// KeyUsage describes how an option was read from a ParameterBag
+type KeyUsage struct {
+	// Key is the full option name, names read from sub bags are prefixed e.g. db.host
+	Key string
+	// Type is the type of the reader e.g. int for ReadInt or the field type for Bind
+	Type string
+	// Default is the default value of the reader or nil for required reads
+	Default interface{}
+	// Required tells if the option was read by at least one ReadRequired* call
+	Required bool
+	// Found tells if the option was found by the last read
+	Found bool
+	// Reads is the number of reads of the option
+	Reads int
+}
+
+/*
+UsageRegistry records option reads of the bags which track their usage with TrackUsage,
+the type and the default value are taken from the first read of an option, so the registry can be used to generate
+an options reference from a test run and to find options which are read but never set or set but never read
+*/
+type UsageRegistry struct {
+	mx     sync.Mutex
+	usages map[string]*KeyUsage
+}
+
+// NewUsageRegistry creates an empty registry
+func NewUsageRegistry() *UsageRegistry {
+	return &UsageRegistry{
+		usages: map[string]*KeyUsage{},
+	}
+}
+
+func (ur *UsageRegistry) record(usage KeyUsage) {
+	ur.mx.Lock()
+	defer ur.mx.Unlock()
+
+	existingUsage, exists := ur.usages[usage.Key]
+	if !exists {
+		usage.Reads = 1
+		ur.usages[usage.Key] = &usage
+		return
+	}
+
+	existingUsage.Reads++
+	existingUsage.Found = usage.Found
+	existingUsage.Required = existingUsage.Required || usage.Required
+}
+
+// Usages gives all recorded option usages sorted by key
+func (ur *UsageRegistry) Usages() []KeyUsage {
+	ur.mx.Lock()
+	defer ur.mx.Unlock()
+
+	usages := make([]KeyUsage, 0, len(ur.usages))
+	for _, usage := range ur.usages {
+		usages = append(usages, *usage)
+	}
+
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].Key < usages[j].Key
+	})
+
+	return usages
+}
+
+// MissingKeys gives sorted names of options which were not found by their last read e.g. because of a typo in a key
+func (ur *UsageRegistry) MissingKeys() []string {
+	keys := []string{}
+	for _, usage := range ur.Usages() {
+		if !usage.Found {
+			keys = append(keys, usage.Key)
+		}
+	}
+
+	return keys
+}
+
+// UnusedKeys gives sorted flat names of options of vp which were never read, an option is used if it was read
+// by its name or by the name of one of its parent objects, vp should be a file provider rather than env variables
+func (ur *UsageRegistry) UnusedKeys(vp ValuesProvider) []string {
+	ur.mx.Lock()
+	defer ur.mx.Unlock()
+
+	keys := []string{}
+	for key := range FlattenKeyValues(vp.ToKeyValues()) {
+		if !ur.isUsed(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (ur *UsageRegistry) isUsed(key string) bool {
+	if _, exists := ur.usages[key]; exists {
+		return true
+	}
+
+	for usedKey := range ur.usages {
+		if strings.HasPrefix(key, usedKey+KeySeparator) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+WriteMarkdown outputs recorded options as a markdown table sorted by key, sensitive defaults are masked
+by CurrentRedactor e.g.
+
+	| Option | Type | Required | Default | Found |
+	| --- | --- | --- | --- | --- |
+	| `db.host` | string | no | "localhost" | yes |
+*/
+func (ur *UsageRegistry) WriteMarkdown(w io.Writer) error {
+	_, err := fmt.Fprintln(w, "| Option | Type | Required | Default | Found |\n| --- | --- | --- | --- | --- |")
+	if err != nil {
+		return err
+	}
+
+	for _, usage := range ur.Usages() {
+		_, err = fmt.Fprintf(
+			w,
+			"| `%s` | %s | %s | %s | %s |\n",
+			usage.Key,
+			usage.Type,
+			env.FormatYesNo(usage.Required),
+			strings.ReplaceAll(formatUsageDefault(usage), "|", `\|`),
+			env.FormatYesNo(usage.Found),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+WriteHelp outputs recorded options as an aligned listing for --help outputs sorted by key, sensitive defaults are masked
+by CurrentRedactor e.g.
+
+	db.host  string  default "localhost"
+	db.port  int     required
+	db.user  string  optional
+*/
+func (ur *UsageRegistry) WriteHelp(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, usage := range ur.Usages() {
+		details := "optional"
+		if usage.Required {
+			details = "required"
+		} else if usage.Default != nil {
+			details = "default " + formatUsageDefault(usage)
+		}
+
+		_, err := fmt.Fprintf(tw, "  %s\t%s\t%s\n", usage.Key, usage.Type, details)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
+
+func formatUsageDefault(usage KeyUsage) string {
+	if usage.Default == nil {
+		return "-"
+	}
+
+	defaultVal := CurrentRedactor().RedactValue(usage.Key, usage.Default)
+	if stringer, ok := defaultVal.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+
+	return formatSourceValue(usage.Key, defaultVal)
+}
+
+// TrackUsage records all option reads of the bag and of its sub bags to the registry
+func (p *ParameterBag) TrackUsage(registry *UsageRegistry) {
+	p.usage = registry
+}
+
+// Usage gives the registry set with TrackUsage or nil if the usage is not tracked
+func (p *ParameterBag) Usage() *UsageRegistry {
+	return p.usage
+}
+
+// untracked gives a bag with the same values which doesn't record usages, it's used by Bind and Validate
+// which record options with their own types
+func (p *ParameterBag) untracked() *ParameterBag {
+	return &ParameterBag{BaseValuesProvider: p.BaseValuesProvider}
+}
+
+func (p *ParameterBag) recordUsage(name, readerType string, defaultVal interface{}, required, found bool) {
+	if p.usage == nil {
+		return
+	}
+
+	key := name
+	if pvp, ok := p.BaseValuesProvider.(*PrefixedValuesProvider); ok {
+		key = pvp.opts.dottedKey(pvp.prefix, name)
+	}
+
+	p.usage.record(KeyUsage{
+		Key:      key,
+		Type:     readerType,
+		Default:  defaultVal,
+		Required: required,
+		Found:    found,
+	})
+}
@@ -0,0 +1,118 @@
+package options
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParameterBagTrackUsage(t *testing.T) {
+	mvp := NewMapValuesProvider(map[string]interface{}{
+		"db": map[string]interface{}{
+			"host": "localhost",
+			"port": 5432,
+		},
+		"debug":      true,
+		"db_pasword": "typo",
+		"tags":       []interface{}{"a"},
+	})
+	pb := New(mvp)
+	assert.Nil(t, pb.Usage())
+
+	registry := NewUsageRegistry()
+	pb.TrackUsage(registry)
+	assert.Equal(t, registry, pb.Usage())
+
+	pb.ReadString("db.host", "127.0.0.1")
+	pb.ReadString("db.host", "other")
+	_, err := pb.ReadRequiredInt("db.port")
+	assert.NoError(t, err)
+	pb.ReadDuration("timeout", time.Second, 5)
+	_, err = pb.ReadRequiredString("db_password")
+	assert.EqualError(t, err, "required option db_password is empty")
+	pb.Read("tags", nil)
+	pb.Sub("cache").ReadString("api_token", "dev-token")
+
+	assert.Equal(
+		t,
+		[]KeyUsage{
+			{Key: "cache.api_token", Type: "string", Default: "dev-token", Found: false, Reads: 1},
+			{Key: "db.host", Type: "string", Default: "127.0.0.1", Found: true, Reads: 2},
+			{Key: "db.port", Type: "int", Required: true, Found: true, Reads: 1},
+			{Key: "db_password", Type: "string", Required: true, Found: false, Reads: 1},
+			{Key: "tags", Type: "value", Found: true, Reads: 1},
+			{Key: "timeout", Type: "duration", Default: time.Second * 5, Found: false, Reads: 1},
+		},
+		registry.Usages(),
+	)
+
+	assert.Equal(t, []string{"cache.api_token", "db_password", "timeout"}, registry.MissingKeys())
+	assert.Equal(t, []string{"db_pasword", "debug"}, registry.UnusedKeys(mvp))
+
+	b := &bytes.Buffer{}
+	err = registry.WriteMarkdown(b)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"| Option | Type | Required | Default | Found |\n"+
+			"| --- | --- | --- | --- | --- |\n"+
+			"| `cache.api_token` | string | no | \"******\" | no |\n"+
+			"| `db.host` | string | no | \"127.0.0.1\" | yes |\n"+
+			"| `db.port` | int | yes | - | yes |\n"+
+			"| `db_password` | string | yes | - | no |\n"+
+			"| `tags` | value | no | - | yes |\n"+
+			"| `timeout` | duration | no | 5s | no |\n",
+		b.String(),
+	)
+
+	b.Reset()
+	err = registry.WriteHelp(b)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		`  cache.api_token  string    default "******"
+  db.host          string    default "127.0.0.1"
+  db.port          int       required
+  db_password      string    required
+  tags             value     optional
+  timeout          duration  default 5s
+`,
+		b.String(),
+	)
+}
+
+func TestBindAndValidateTrackUsage(t *testing.T) {
+	pb := New(NewMapValuesProvider(map[string]interface{}{
+		"db": map[string]interface{}{"port": "5432"},
+	}))
+	registry := NewUsageRegistry()
+	pb.TrackUsage(registry)
+
+	type config struct {
+		Host    string        `param:"host" default:"localhost"`
+		Port    int           `param:"port" required:"true"`
+		Timeout time.Duration `param:"timeout"`
+	}
+	cfg := struct {
+		DB config `param:"db"`
+	}{}
+
+	err := pb.Bind(&cfg)
+	assert.NoError(t, err)
+
+	err = pb.Validate(NewSchema(OptionSpec{Key: "db.port", Type: TypeInt, Required: true}, OptionSpec{Key: "name", Default: "app"}))
+	assert.NoError(t, err)
+
+	assert.Equal(
+		t,
+		[]KeyUsage{
+			{Key: "db.host", Type: "string", Default: "localhost", Found: false, Reads: 1},
+			{Key: "db.port", Type: "int", Required: true, Found: true, Reads: 2},
+			{Key: "db.timeout", Type: "time.Duration", Found: false, Reads: 1},
+			{Key: "name", Type: "string", Default: "app", Found: false, Reads: 1},
+		},
+		registry.Usages(),
+	)
+}
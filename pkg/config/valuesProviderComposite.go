@@ -0,0 +1,279 @@
+package options
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Layer is a named values provider of ValuesProviderComposite, the name is used as its label in explanations
+type Layer struct {
+	Name     string
+	Provider ValuesProvider
+}
+
+/*
+ValuesProviderComposite combines layers of values providers, layers are ordered by their precedence,
+so the first layer which has a value wins in Read, ToKeyValues, Dump and Explain, nested objects are merged,
+e.g. {"db": {"host": "a"}} of the first layer and {"db": {"host": "b", "port": 1}} of the second one
+give {"db": {"host": "a", "port": 1}}, layers can be added or removed at runtime e.g. to override values in tests
+*/
+type ValuesProviderComposite struct {
+	mx     sync.RWMutex
+	layers []Layer
+}
+
+// NewValuesProviderComposite combines vps in the order of their precedence, layers are named by the provider labels
+func NewValuesProviderComposite(vps ...ValuesProvider) *ValuesProviderComposite {
+	layers := make([]Layer, 0, len(vps))
+	for _, vp := range vps {
+		layers = append(layers, newLayer("", vp))
+	}
+
+	return &ValuesProviderComposite{layers: layers}
+}
+
+// NewLayeredValuesProviderComposite combines layers in the order of their precedence, layers without a name
+// are named by the provider labels
+func NewLayeredValuesProviderComposite(layers ...Layer) *ValuesProviderComposite {
+	vpc := &ValuesProviderComposite{
+		layers: make([]Layer, 0, len(layers)),
+	}
+	for _, layer := range layers {
+		vpc.layers = append(vpc.layers, newLayer(layer.Name, layer.Provider))
+	}
+
+	return vpc
+}
+
+func newLayer(name string, vp ValuesProvider) Layer {
+	if vp == nil {
+		vp = &NullValuesProvider{}
+	}
+
+	if name == "" {
+		name = ProviderLabel(vp)
+	}
+
+	return Layer{Name: name, Provider: vp}
+}
+
+// providerLayers gives layers of a composite or a single layer of any other values provider
+func providerLayers(vp ValuesProvider) []Layer {
+	if vpc, ok := vp.(*ValuesProviderComposite); ok {
+		return vpc.Layers()
+	}
+
+	return []Layer{newLayer("", vp)}
+}
+
+// Layers gives a copy of the layers in the order of their precedence
+func (vpc *ValuesProviderComposite) Layers() []Layer {
+	vpc.mx.RLock()
+	defer vpc.mx.RUnlock()
+
+	layers := make([]Layer, len(vpc.layers))
+	copy(layers, vpc.layers)
+
+	return layers
+}
+
+// Layer gives the provider of the first layer with the name
+func (vpc *ValuesProviderComposite) Layer(name string) (ValuesProvider, bool) {
+	vpc.mx.RLock()
+	defer vpc.mx.RUnlock()
+
+	index := vpc.layerIndex(name)
+	if index < 0 {
+		return nil, false
+	}
+
+	return vpc.layers[index].Provider, true
+}
+
+func (vpc *ValuesProviderComposite) layerIndex(name string) int {
+	for i := range vpc.layers {
+		if vpc.layers[i].Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// PushLayer adds a layer with the highest precedence e.g. to override values in tests
+func (vpc *ValuesProviderComposite) PushLayer(name string, vp ValuesProvider) {
+	vpc.mx.Lock()
+	defer vpc.mx.Unlock()
+
+	vpc.insertLayer(0, newLayer(name, vp))
+}
+
+// AddLayer adds a layer with the lowest precedence e.g. with default values
+func (vpc *ValuesProviderComposite) AddLayer(name string, vp ValuesProvider) {
+	vpc.mx.Lock()
+	defer vpc.mx.Unlock()
+
+	vpc.insertLayer(len(vpc.layers), newLayer(name, vp))
+}
+
+// InsertLayerBefore adds a layer which takes precedence over the first layer named existingName
+func (vpc *ValuesProviderComposite) InsertLayerBefore(existingName, name string, vp ValuesProvider) error {
+	vpc.mx.Lock()
+	defer vpc.mx.Unlock()
+
+	index := vpc.layerIndex(existingName)
+	if index < 0 {
+		return fmt.Errorf("layer %s is not found", existingName)
+	}
+
+	vpc.insertLayer(index, newLayer(name, vp))
+
+	return nil
+}
+
+// InsertLayerAfter adds a layer which is shadowed by the first layer named existingName
+func (vpc *ValuesProviderComposite) InsertLayerAfter(existingName, name string, vp ValuesProvider) error {
+	vpc.mx.Lock()
+	defer vpc.mx.Unlock()
+
+	index := vpc.layerIndex(existingName)
+	if index < 0 {
+		return fmt.Errorf("layer %s is not found", existingName)
+	}
+
+	vpc.insertLayer(index+1, newLayer(name, vp))
+
+	return nil
+}
+
+// insertLayer replaces the layers slice, so copies given by Layers are not changed
+func (vpc *ValuesProviderComposite) insertLayer(index int, layer Layer) {
+	layers := make([]Layer, 0, len(vpc.layers)+1)
+	layers = append(layers, vpc.layers[:index]...)
+	layers = append(layers, layer)
+	layers = append(layers, vpc.layers[index:]...)
+	vpc.layers = layers
+}
+
+// RemoveLayer removes the first layer with the name and gives false if it wasn't found
+func (vpc *ValuesProviderComposite) RemoveLayer(name string) bool {
+	vpc.mx.Lock()
+	defer vpc.mx.Unlock()
+
+	index := vpc.layerIndex(name)
+	if index < 0 {
+		return false
+	}
+
+	layers := make([]Layer, 0, len(vpc.layers)-1)
+	layers = append(layers, vpc.layers[:index]...)
+	layers = append(layers, vpc.layers[index+1:]...)
+	vpc.layers = layers
+
+	return true
+}
+
+/*
+Providers gives the combined providers in the order of their precedence, providers of layers with a custom name
+are wrapped with LabeledValuesProvider, so the layer name is shown in explanations, layers which combine
+other providers are given as they are
+*/
+func (vpc *ValuesProviderComposite) Providers() []ValuesProvider {
+	layers := vpc.Layers()
+	providers := make([]ValuesProvider, 0, len(layers))
+	for _, layer := range layers {
+		if combinesProviders(layer.Provider) || layer.Name == ProviderLabel(layer.Provider) {
+			providers = append(providers, layer.Provider)
+			continue
+		}
+		providers = append(providers, NewLabeledValuesProvider(layer.Name, layer.Provider))
+	}
+
+	return providers
+}
+
+func (vpc *ValuesProviderComposite) Read(name string) (val interface{}, found bool) {
+	val, found, _ = vpc.ReadWithError(name)
+	return
+}
+
+// ReadWithError gives the first found value or the first read error of the layers, if the found value is an object,
+// it's merged with objects of the following layers
+func (vpc *ValuesProviderComposite) ReadWithError(name string) (val interface{}, found bool, err error) {
+	for _, layer := range vpc.Layers() {
+		layerVal, layerFound, layerErr := ReadValue(layer.Provider, name)
+		if layerErr != nil {
+			return nil, false, layerErr
+		}
+		if !layerFound {
+			continue
+		}
+
+		if !found {
+			val, found = layerVal, true
+		} else {
+			val = mergeValues(val, layerVal)
+		}
+
+		if _, isObject := val.(map[string]interface{}); !isObject {
+			return val, true, nil
+		}
+	}
+
+	return
+}
+
+// ToKeyValues gives values of all layers, values of a layer shadow values of the following layers
+// and nested objects are merged
+func (vpc *ValuesProviderComposite) ToKeyValues() map[string]interface{} {
+	layers := vpc.Layers()
+	res := map[string]interface{}{}
+	for i := len(layers) - 1; i >= 0; i-- {
+		res = mergeKeyValues(layers[i].Provider.ToKeyValues(), res)
+	}
+
+	return res
+}
+
+// Dump outputs values of all providers as json, sensitive values are masked by CurrentRedactor
+func (vpc *ValuesProviderComposite) Dump(w io.Writer) (err error) {
+	kvs := CurrentRedactor().Redact(vpc.ToKeyValues())
+	jsonEncoder := json.NewEncoder(w)
+	return jsonEncoder.Encode(kvs)
+}
+
+// DumpFormatted outputs values in the given format, see DumpFormatted
+func (vpc *ValuesProviderComposite) DumpFormatted(w io.Writer, format DumpFormat) error {
+	return DumpFormatted(vpc, w, format)
+}
+
+// mergeKeyValues gives a new map with values of both maps, values of prior shadow values of other
+func mergeKeyValues(prior, other map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(prior)+len(other))
+	for key, val := range other {
+		res[key] = val
+	}
+
+	for key, val := range prior {
+		if otherVal, exists := res[key]; exists {
+			res[key] = mergeValues(val, otherVal)
+			continue
+		}
+		res[key] = val
+	}
+
+	return res
+}
+
+// mergeValues merges two objects or gives prior if any of the values is not an object
+func mergeValues(prior, other interface{}) interface{} {
+	priorMap, isPriorMap := prior.(map[string]interface{})
+	otherMap, isOtherMap := other.(map[string]interface{})
+	if !isPriorMap || !isOtherMap {
+		return prior
+	}
+
+	return mergeKeyValues(priorMap, otherMap)
+}
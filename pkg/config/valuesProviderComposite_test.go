@@ -0,0 +1,176 @@
+package options
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValuesProviderCompositePrecedence(t *testing.T) {
+	overrides := NewMapValuesProvider(map[string]interface{}{
+		"host": "overrideHost",
+		"db": map[string]interface{}{
+			"host": "overrideDbHost",
+		},
+	})
+	defaults := NewMapValuesProvider(map[string]interface{}{
+		"host": "defaultHost",
+		"port": 80,
+		"db": map[string]interface{}{
+			"host": "defaultDbHost",
+			"port": 5432,
+		},
+	})
+	vpc := NewValuesProviderComposite(overrides, defaults)
+
+	val, found := vpc.Read("host")
+	assert.True(t, found)
+	assert.Equal(t, "overrideHost", val)
+
+	val, found = vpc.Read("db")
+	assert.True(t, found)
+	assert.Equal(t, map[string]interface{}{"host": "overrideDbHost", "port": 5432}, val)
+
+	val, found = vpc.Read("db.port")
+	assert.True(t, found)
+	assert.Equal(t, 5432, val)
+
+	expectedKvs := map[string]interface{}{
+		"host": "overrideHost",
+		"port": 80,
+		"db": map[string]interface{}{
+			"host": "overrideDbHost",
+			"port": 5432,
+		},
+	}
+	assert.Equal(t, expectedKvs, vpc.ToKeyValues())
+
+	b := &bytes.Buffer{}
+	err := vpc.Dump(b)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"db":{"host":"overrideDbHost","port":5432},"host":"overrideHost","port":80}`+"\n", b.String())
+
+	assert.Equal(t, map[string]interface{}{"host": "defaultDbHost", "port": 5432}, defaults.ToKeyValues()["db"])
+}
+
+func TestValuesProviderCompositeReadError(t *testing.T) {
+	vpc := NewValuesProviderComposite(
+		NewMapValuesProvider(map[string]interface{}{"db": map[string]interface{}{"host": "localhost"}}),
+		&failingValuesProviderMock{err: errors.New("broken provider")},
+	)
+
+	_, found, err := vpc.ReadWithError("db.host")
+	assert.NoError(t, err)
+	assert.True(t, found)
+
+	_, found, err = vpc.ReadWithError("db")
+	assert.EqualError(t, err, "broken provider")
+	assert.False(t, found)
+}
+
+func TestValuesProviderCompositeLayers(t *testing.T) {
+	vpc := NewLayeredValuesProviderComposite(
+		Layer{Name: "file", Provider: NewMapValuesProvider(map[string]interface{}{"host": "fileHost"})},
+		Layer{Provider: NewMapValuesProvider(map[string]interface{}{"host": "defaultHost", "port": 80})},
+	)
+	assert.Equal(t, []string{"file", "map"}, layerNames(vpc))
+
+	vpc.PushLayer("test", NewMapValuesProvider(map[string]interface{}{"host": "testHost"}))
+	vpc.AddLayer("fallback", NewMapValuesProvider(map[string]interface{}{"timeout": 10}))
+	err := vpc.InsertLayerBefore("map", "env", NewMapValuesProvider(map[string]interface{}{"port": 8080}))
+	assert.NoError(t, err)
+	err = vpc.InsertLayerAfter("test", "remote", NewMapValuesProvider(map[string]interface{}{"host": "remoteHost"}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"test", "remote", "file", "env", "map", "fallback"}, layerNames(vpc))
+
+	pb := New(vpc)
+	assert.Equal(t, "testHost", pb.ReadString("host", ""))
+	assert.Equal(t, 8080, pb.ReadInt("port", 0))
+	assert.Equal(t, 10, pb.ReadInt("timeout", 0))
+
+	expl := pb.Explain("host")
+	assert.Equal(t, "test", expl.Source.Label)
+	assert.Equal(t, "remote", expl.Shadowed[0].Label)
+	assert.Equal(t, "file", expl.Shadowed[1].Label)
+	assert.Equal(t, "map", expl.Shadowed[2].Label)
+
+	layerVP, found := vpc.Layer("remote")
+	assert.True(t, found)
+	assert.Equal(t, map[string]interface{}{"host": "remoteHost"}, layerVP.ToKeyValues())
+
+	assert.True(t, vpc.RemoveLayer("test"))
+	assert.False(t, vpc.RemoveLayer("test"))
+	assert.Equal(t, "remoteHost", pb.ReadString("host", ""))
+
+	_, found = vpc.Layer("test")
+	assert.False(t, found)
+
+	err = vpc.InsertLayerBefore("unknown", "other", nil)
+	assert.EqualError(t, err, "layer unknown is not found")
+	err = vpc.InsertLayerAfter("unknown", "other", nil)
+	assert.EqualError(t, err, "layer unknown is not found")
+}
+
+func TestValuesProviderCompositeConcurrentLayers(t *testing.T) {
+	vpc := NewValuesProviderComposite(NewMapValuesProvider(map[string]interface{}{"host": "defaultHost"}))
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			vpc.PushLayer("test", NewMapValuesProvider(map[string]interface{}{"host": "testHost"}))
+			vpc.RemoveLayer("test")
+		}()
+		go func() {
+			defer wg.Done()
+			val, found := vpc.Read("host")
+			assert.True(t, found)
+			assert.Contains(t, []interface{}{"defaultHost", "testHost"}, val)
+			assert.Contains(t, []interface{}{"defaultHost", "testHost"}, vpc.ToKeyValues()["host"])
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, []string{"map"}, layerNames(vpc))
+}
+
+func TestParameterBagMergePrecedence(t *testing.T) {
+	base := NewValuesProviderComposite(
+		NewMapValuesProvider(map[string]interface{}{"host": "baseHost"}),
+	)
+	pb := New(base)
+	pb.MergeParameterBag(New(NewLayeredValuesProviderComposite(
+		Layer{Name: "defaults", Provider: NewMapValuesProvider(map[string]interface{}{"host": "defaultHost", "port": 80})},
+	)))
+
+	assert.Equal(t, "baseHost", pb.ReadString("host", ""))
+	assert.Equal(t, 80, pb.ReadInt("port", 0))
+	assert.Equal(t, map[string]interface{}{"host": "baseHost", "port": 80}, pb.BaseValuesProvider.ToKeyValues())
+
+	vpc, ok := pb.BaseValuesProvider.(*ValuesProviderComposite)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"map", "defaults"}, layerNames(vpc))
+	assert.Equal(t, []string{"map"}, layerNames(base))
+}
+
+func layerNames(vpc *ValuesProviderComposite) []string {
+	names := []string{}
+	for _, layer := range vpc.Layers() {
+		names = append(names, layer.Name)
+	}
+
+	return names
+}
+
+type failingValuesProviderMock struct {
+	NullValuesProvider
+	err error
+}
+
+func (fvpm *failingValuesProviderMock) ReadWithError(name string) (val interface{}, found bool, err error) {
+	return nil, false, fvpm.err
+}
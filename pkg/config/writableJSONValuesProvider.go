@@ -0,0 +1,399 @@
+package options
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/breathbath/go_utils/v3/pkg/types"
+)
+
+// WritableValuesProvider is implemented by values providers which can change and persist their values
+type WritableValuesProvider interface {
+	ValuesProvider
+	Set(name string, val interface{}) error
+	Delete(name string) bool
+	Save(w io.Writer) error
+}
+
+// orderedObject is a json object which keeps the order of its keys
+type orderedObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newOrderedObject() *orderedObject {
+	return &orderedObject{
+		keys:   []string{},
+		values: map[string]interface{}{},
+	}
+}
+
+func (oo *orderedObject) set(key string, val interface{}) {
+	if _, exists := oo.values[key]; !exists {
+		oo.keys = append(oo.keys, key)
+	}
+	oo.values[key] = val
+}
+
+func (oo *orderedObject) delete(key string) bool {
+	if _, exists := oo.values[key]; !exists {
+		return false
+	}
+
+	delete(oo.values, key)
+	for i, existingKey := range oo.keys {
+		if existingKey == key {
+			oo.keys = append(oo.keys[:i], oo.keys[i+1:]...)
+			break
+		}
+	}
+
+	return true
+}
+
+func (oo *orderedObject) MarshalJSON() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString("{")
+	for i, key := range oo.keys {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+
+		keyData, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+
+		valData, err := json.Marshal(revealSecrets(oo.values[key]))
+		if err != nil {
+			return nil, fmt.Errorf("cannot encode option %s: %v", key, err)
+		}
+
+		buf.Write(keyData)
+		buf.WriteString(":")
+		buf.Write(valData)
+	}
+	buf.WriteString("}")
+
+	return buf.Bytes(), nil
+}
+
+// revealSecrets gives original values of types.Secret, since their json representation is masked and can't be loaded back
+func revealSecrets(val interface{}) interface{} {
+	switch typedVal := val.(type) {
+	case types.Secret:
+		return typedVal.Reveal()
+	case *types.Secret:
+		if typedVal == nil {
+			return nil
+		}
+		return typedVal.Reveal()
+	case []interface{}:
+		res := make([]interface{}, 0, len(typedVal))
+		for _, item := range typedVal {
+			res = append(res, revealSecrets(item))
+		}
+		return res
+	default:
+		return val
+	}
+}
+
+/*
+WritableJSONValuesProvider gives values of a json document which can be changed with Set and Delete and written back
+with Save, the order of keys and the original representation of numbers are kept, so unchanged values are saved
+as they were read, values are given with the same types as in NewJSONValuesProvider, all methods are concurrency safe
+*/
+type WritableJSONValuesProvider struct {
+	mx   sync.RWMutex
+	root *orderedObject
+}
+
+// NewWritableJSONValuesProvider reads a json object from jsonReader, an empty input gives an empty object
+func NewWritableJSONValuesProvider(jsonReader io.Reader) (*WritableJSONValuesProvider, error) {
+	data, err := io.ReadAll(jsonReader)
+	if err != nil {
+		return nil, err
+	}
+
+	root := newOrderedObject()
+	if len(bytes.TrimSpace(data)) > 0 {
+		root, err = parseOrderedJSON(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &WritableJSONValuesProvider{root: root}, nil
+}
+
+func parseOrderedJSON(data []byte) (*orderedObject, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	rootVal, err := parseOrderedValue(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	root, ok := rootVal.(*orderedObject)
+	if !ok {
+		return nil, errors.New("json config should be an object")
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the json config object")
+	}
+
+	return root, nil
+}
+
+func parseOrderedValue(dec *json.Decoder) (interface{}, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		obj := newOrderedObject()
+		for dec.More() {
+			keyToken, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			val, err := parseOrderedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj.set(fmt.Sprint(keyToken), val)
+		}
+		_, err = dec.Token()
+		return obj, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for dec.More() {
+			val, err := parseOrderedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, val)
+		}
+		_, err = dec.Token()
+		return list, err
+	default:
+		return token, nil
+	}
+}
+
+// toOrderedValue converts maps given to Set to ordered objects with sorted keys, so they can be changed by nested names
+func toOrderedValue(val interface{}) interface{} {
+	switch typedVal := val.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(typedVal))
+		for key := range typedVal {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		obj := newOrderedObject()
+		for _, key := range keys {
+			obj.set(key, toOrderedValue(typedVal[key]))
+		}
+		return obj
+	case []interface{}:
+		list := make([]interface{}, 0, len(typedVal))
+		for _, item := range typedVal {
+			list = append(list, toOrderedValue(item))
+		}
+		return list
+	default:
+		return val
+	}
+}
+
+// toPlainValue converts ordered objects to maps and json numbers to int or float64 as in NewJSONValuesProvider
+func toPlainValue(val interface{}) interface{} {
+	switch typedVal := val.(type) {
+	case *orderedObject:
+		res := make(map[string]interface{}, len(typedVal.keys))
+		for _, key := range typedVal.keys {
+			res[key] = toPlainValue(typedVal.values[key])
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, 0, len(typedVal))
+		for _, item := range typedVal {
+			res = append(res, toPlainValue(item))
+		}
+		return res
+	case json.Number:
+		return convertJSONNumbers(typedVal)
+	default:
+		return val
+	}
+}
+
+func (wjvp *WritableJSONValuesProvider) Read(name string) (val interface{}, found bool) {
+	wjvp.mx.RLock()
+	defer wjvp.mx.RUnlock()
+
+	load := func(key interface{}) (interface{}, bool) {
+		keyVal, exists := wjvp.root.values[fmt.Sprint(key)]
+		if !exists {
+			return nil, false
+		}
+		return toPlainValue(keyVal), true
+	}
+
+	val, found = load(name)
+	if found {
+		return
+	}
+
+	return lookupNestedKey(name, load)
+}
+
+/*
+Set changes the value of an option, an existing key with the full name e.g. "db.host" is changed directly,
+otherwise the name is split by KeySeparator and missing objects are created, so db.host sets {"db": {"host": val}},
+setting a nested option of a non object value fails
+*/
+func (wjvp *WritableJSONValuesProvider) Set(name string, val interface{}) error {
+	wjvp.mx.Lock()
+	defer wjvp.mx.Unlock()
+
+	if _, exists := wjvp.root.values[name]; exists {
+		wjvp.root.set(name, toOrderedValue(val))
+		return nil
+	}
+
+	obj := wjvp.root
+	path := strings.Split(name, KeySeparator)
+	for i, pathItem := range path[:len(path)-1] {
+		childVal, exists := obj.values[pathItem]
+		if !exists {
+			child := newOrderedObject()
+			obj.set(pathItem, child)
+			obj = child
+			continue
+		}
+
+		child, ok := childVal.(*orderedObject)
+		if !ok {
+			return fmt.Errorf("cannot set option %s: %s is not an object", name, strings.Join(path[:i+1], KeySeparator))
+		}
+		obj = child
+	}
+
+	obj.set(path[len(path)-1], toOrderedValue(val))
+
+	return nil
+}
+
+// Delete removes an option by its full name or by its nested name and gives false if it wasn't found
+func (wjvp *WritableJSONValuesProvider) Delete(name string) bool {
+	wjvp.mx.Lock()
+	defer wjvp.mx.Unlock()
+
+	if wjvp.root.delete(name) {
+		return true
+	}
+
+	obj := wjvp.root
+	path := strings.Split(name, KeySeparator)
+	for _, pathItem := range path[:len(path)-1] {
+		child, ok := obj.values[pathItem].(*orderedObject)
+		if !ok {
+			return false
+		}
+		obj = child
+	}
+
+	return obj.delete(path[len(path)-1])
+}
+
+// Save writes all values as indented json in the original order of keys, sensitive values are not masked
+// and types.Secret values are written as their original values, so they are read back unchanged
+func (wjvp *WritableJSONValuesProvider) Save(w io.Writer) error {
+	wjvp.mx.RLock()
+	data, err := json.MarshalIndent(wjvp.root, "", "  ")
+	wjvp.mx.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	data = append(data, '\n')
+	_, err = w.Write(data)
+
+	return err
+}
+
+func (wjvp *WritableJSONValuesProvider) ToKeyValues() map[string]interface{} {
+	wjvp.mx.RLock()
+	defer wjvp.mx.RUnlock()
+
+	return toPlainValue(wjvp.root).(map[string]interface{})
+}
+
+// Dump outputs values as json, sensitive values are masked by CurrentRedactor, use Save to persist the values
+func (wjvp *WritableJSONValuesProvider) Dump(w io.Writer) (err error) {
+	data := CurrentRedactor().Redact(wjvp.ToKeyValues())
+	jsonEncoder := json.NewEncoder(w)
+	return jsonEncoder.Encode(data)
+}
+
+// DumpFormatted outputs values in the given format, see DumpFormatted
+func (wjvp *WritableJSONValuesProvider) DumpFormatted(w io.Writer, format DumpFormat) error {
+	return DumpFormatted(wjvp, w, format)
+}
+
+func (wjvp *WritableJSONValuesProvider) Label() string {
+	return "writable_json"
+}
+
+// Set changes the option value if the bag values provider implements WritableValuesProvider
+func (p *ParameterBag) Set(name string, val interface{}) error {
+	wvp, err := p.writableProvider()
+	if err != nil {
+		return err
+	}
+
+	return wvp.Set(name, val)
+}
+
+// Delete removes the option if the bag values provider implements WritableValuesProvider
+func (p *ParameterBag) Delete(name string) (bool, error) {
+	wvp, err := p.writableProvider()
+	if err != nil {
+		return false, err
+	}
+
+	return wvp.Delete(name), nil
+}
+
+// Save persists the options if the bag values provider implements WritableValuesProvider
+func (p *ParameterBag) Save(w io.Writer) error {
+	wvp, err := p.writableProvider()
+	if err != nil {
+		return err
+	}
+
+	return wvp.Save(w)
+}
+
+func (p *ParameterBag) writableProvider() (WritableValuesProvider, error) {
+	wvp, ok := p.BaseValuesProvider.(WritableValuesProvider)
+	if !ok {
+		return nil, fmt.Errorf("values provider %s is not writable", ProviderLabel(p.BaseValuesProvider))
+	}
+
+	return wvp, nil
+}
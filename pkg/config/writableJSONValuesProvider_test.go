@@ -0,0 +1,223 @@
+package options
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/breathbath/go_utils/v3/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const writableJSONInput = `{
+  "name": "app",
+  "db": {
+    "port": 5432,
+    "host": "localhost",
+    "password": "secret"
+  },
+  "ratio": 1.50,
+  "big": 12345678901234567890,
+  "debug": false,
+  "tags": [
+    "a",
+    1,
+    null
+  ],
+  "db.flat": "flatVal"
+}`
+
+func TestWritableJSONValuesProviderRoundTrip(t *testing.T) {
+	wjvp, err := NewWritableJSONValuesProvider(strings.NewReader(writableJSONInput))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	b := &bytes.Buffer{}
+	err = wjvp.Save(b)
+	assert.NoError(t, err)
+	assert.Equal(t, writableJSONInput+"\n", b.String())
+
+	jsonProvider, err := NewJSONValuesProvider(strings.NewReader(b.String()))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+	assert.Equal(t, jsonProvider.ToKeyValues(), wjvp.ToKeyValues())
+
+	pb := New(wjvp)
+	assert.Equal(t, 5432, pb.ReadInt("db.port", 0))
+	assert.Equal(t, "flatVal", pb.ReadString("db.flat", ""))
+	assert.Equal(t, 1.5, pb.BaseValuesProvider.ToKeyValues()["ratio"])
+	assert.Equal(t, []string{"a", "1", ""}, pb.ReadStrings("tags"))
+}
+
+func TestWritableJSONValuesProviderChanges(t *testing.T) {
+	wjvp, err := NewWritableJSONValuesProvider(strings.NewReader(writableJSONInput))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	pb := New(wjvp)
+	assert.NoError(t, pb.Set("db.host", "remote"))
+	assert.NoError(t, pb.Set("db.flat", "changed"))
+	assert.NoError(t, pb.Set("cache.redis.host", "redis"))
+	assert.NoError(t, pb.Set("limits", map[string]interface{}{"max": 10, "min": 1}))
+	assert.NoError(t, pb.Set("limits.avg", 5))
+	assert.NoError(t, pb.Set("name", "renamed"))
+	assert.EqualError(t, pb.Set("name.first", "x"), "cannot set option name.first: name is not an object")
+
+	deleted, err := pb.Delete("db.password")
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	deleted, err = pb.Delete("big")
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	deleted, err = pb.Delete("db.missing")
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+
+	deleted, err = pb.Delete("name.first")
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+
+	assert.Equal(t, "remote", pb.ReadString("db.host", ""))
+	assert.Equal(t, "redis", pb.ReadString("cache.redis.host", ""))
+	assert.Equal(t, 5, pb.ReadInt("limits.avg", 0))
+	_, found := pb.Read("db.password", nil)
+	assert.False(t, found)
+
+	b := &bytes.Buffer{}
+	err = pb.Save(b)
+	assert.NoError(t, err)
+	assert.Equal(t, `{
+  "name": "renamed",
+  "db": {
+    "port": 5432,
+    "host": "remote"
+  },
+  "ratio": 1.50,
+  "debug": false,
+  "tags": [
+    "a",
+    1,
+    null
+  ],
+  "db.flat": "changed",
+  "cache": {
+    "redis": {
+      "host": "redis"
+    }
+  },
+  "limits": {
+    "max": 10,
+    "min": 1,
+    "avg": 5
+  }
+}
+`, b.String())
+
+	dumpBuf := &bytes.Buffer{}
+	err = wjvp.Dump(dumpBuf)
+	assert.NoError(t, err)
+	assert.Contains(t, dumpBuf.String(), `"host":"remote"`)
+}
+
+func TestWritableJSONValuesProviderSavesSecrets(t *testing.T) {
+	wjvp, err := NewWritableJSONValuesProvider(strings.NewReader(`{"db":{"pw":"old"}}`))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	assert.NoError(t, wjvp.Set("db.pw", types.NewSecret("hunter2")))
+	assert.NoError(t, wjvp.Set("keys", []interface{}{types.NewSecret("k1")}))
+
+	dumpBuf := &bytes.Buffer{}
+	err = wjvp.Dump(dumpBuf)
+	assert.NoError(t, err)
+	assert.NotContains(t, dumpBuf.String(), "hunter2")
+	assert.NotContains(t, dumpBuf.String(), "k1")
+
+	b := &bytes.Buffer{}
+	err = wjvp.Save(b)
+	assert.NoError(t, err)
+
+	loaded, err := NewWritableJSONValuesProvider(b)
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	pb := New(loaded)
+	assert.Equal(t, "hunter2", pb.ReadString("db.pw", ""))
+	assert.Equal(t, []string{"k1"}, pb.ReadStrings("keys"))
+}
+
+func TestWritableJSONValuesProviderInputs(t *testing.T) {
+	wjvp, err := NewWritableJSONValuesProvider(strings.NewReader("  "))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+	assert.Equal(t, map[string]interface{}{}, wjvp.ToKeyValues())
+
+	b := &bytes.Buffer{}
+	assert.NoError(t, wjvp.Save(b))
+	assert.Equal(t, "{}\n", b.String())
+
+	_, err = NewWritableJSONValuesProvider(strings.NewReader(`["a"]`))
+	assert.EqualError(t, err, "json config should be an object")
+
+	_, err = NewWritableJSONValuesProvider(strings.NewReader(`{"a": 1} {"b": 2}`))
+	assert.EqualError(t, err, "unexpected data after the json config object")
+
+	_, err = NewWritableJSONValuesProvider(strings.NewReader(`{"a": `))
+	assert.Error(t, err)
+
+	_, err = NewWritableJSONValuesProvider(failingReader{})
+	assert.Error(t, err)
+
+	err = New(NewMapValuesProvider(map[string]interface{}{})).Set("key", "val")
+	assert.EqualError(t, err, "values provider map is not writable")
+
+	_, err = New(nil).Delete("key")
+	assert.EqualError(t, err, "values provider null is not writable")
+
+	err = New(nil).Save(b)
+	assert.EqualError(t, err, "values provider null is not writable")
+
+	assert.NoError(t, wjvp.Set("ch", make(chan int)))
+	assert.Error(t, wjvp.Save(b))
+}
+
+func TestWritableJSONValuesProviderConcurrency(t *testing.T) {
+	wjvp, err := NewWritableJSONValuesProvider(strings.NewReader(writableJSONInput))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprintf("workers.w%d", i)
+			assert.NoError(t, wjvp.Set(key, i))
+			wjvp.Read(key)
+			wjvp.ToKeyValues()
+			assert.NoError(t, wjvp.Save(&bytes.Buffer{}))
+			wjvp.Delete("db.host")
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Len(t, wjvp.ToKeyValues()["workers"], 10)
+}
@@ -0,0 +1,100 @@
+package options
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/breathbath/go_utils/v3/pkg/conv"
+	"gopkg.in/yaml.v3"
+)
+
+// YAMLValuesProvider gives values from a yaml document, top level keys are used as option names
+type YAMLValuesProvider struct {
+	vals MapValuesProvider
+}
+
+// NewYAMLValuesProvider reads yaml data from the provided reader, scalar values are kept as strings, ints, floats,
+// bools or nils, lists and nested objects are kept as []interface{} and map[string]interface{}
+func NewYAMLValuesProvider(yamld io.Reader) (yvp *YAMLValuesProvider, err error) {
+	var data []byte
+	data, err = io.ReadAll(yamld)
+	if err != nil {
+		return
+	}
+
+	objmap, err := parseYAMLValues(data)
+	if err != nil {
+		return
+	}
+
+	return &YAMLValuesProvider{
+		vals: MapValuesProvider{
+			parameters: conv.ConvertMapToSyncMap(objmap),
+		},
+	}, nil
+}
+
+func parseYAMLValues(data []byte) (map[string]interface{}, error) {
+	objmap := map[string]interface{}{}
+	err := yaml.Unmarshal(data, &objmap)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, val := range objmap {
+		objmap[k] = normalizeYAMLValue(val)
+	}
+
+	return objmap, nil
+}
+
+// normalizeYAMLValue converts map[interface{}]interface{} which yaml produces for non string keys
+// to map[string]interface{} so the values can be json encoded
+func normalizeYAMLValue(val interface{}) interface{} {
+	switch typedVal := val.(type) {
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(typedVal))
+		for k, v := range typedVal {
+			res[fmt.Sprint(k)] = normalizeYAMLValue(v)
+		}
+		return res
+	case map[string]interface{}:
+		for k, v := range typedVal {
+			typedVal[k] = normalizeYAMLValue(v)
+		}
+		return typedVal
+	case []interface{}:
+		for i, v := range typedVal {
+			typedVal[i] = normalizeYAMLValue(v)
+		}
+		return typedVal
+	default:
+		return val
+	}
+}
+
+// SetKeysMode defines if ToKeyValues and Dump should give nested values as a tree or as flat dotted keys
+func (yvp *YAMLValuesProvider) SetKeysMode(mode KeysMode) {
+	yvp.vals.SetKeysMode(mode)
+}
+
+func (yvp *YAMLValuesProvider) Read(name string) (val interface{}, found bool) {
+	return yvp.vals.Read(name)
+}
+
+func (yvp *YAMLValuesProvider) Dump(w io.Writer) (err error) {
+	return yvp.vals.Dump(w)
+}
+
+// DumpFormatted outputs values in the given format, see DumpFormatted
+func (yvp *YAMLValuesProvider) DumpFormatted(w io.Writer, format DumpFormat) error {
+	return DumpFormatted(yvp, w, format)
+}
+
+func (yvp *YAMLValuesProvider) Label() string {
+	return "yaml"
+}
+
+func (yvp *YAMLValuesProvider) ToKeyValues() map[string]interface{} {
+	return yvp.vals.ToKeyValues()
+}
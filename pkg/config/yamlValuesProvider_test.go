@@ -0,0 +1,136 @@
+package options
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const yamlInput = `
+key1: val1
+key2: 2
+key3: 3.3
+key4: null
+key5: ""
+key6: true
+key7:
+  - one
+  - two
+key8: [1, 2.5, false]
+`
+
+func TestYamlValuesProviderRead(t *testing.T) {
+	yvp, err := NewYAMLValuesProvider(strings.NewReader(yamlInput))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	val, found := yvp.Read("key1")
+	assert.True(t, found)
+	assert.Equal(t, "val1", val)
+
+	val2, found2 := yvp.Read("key2")
+	assert.True(t, found2)
+	assert.Equal(t, 2, val2)
+
+	val3, found3 := yvp.Read("key3")
+	assert.True(t, found3)
+	assert.Equal(t, 3.3, val3)
+
+	val4, found4 := yvp.Read("key4")
+	assert.True(t, found4)
+	assert.Equal(t, nil, val4)
+
+	val5, found5 := yvp.Read("key5")
+	assert.True(t, found5)
+	assert.Equal(t, "", val5)
+
+	val6, found6 := yvp.Read("key6")
+	assert.True(t, found6)
+	assert.Equal(t, true, val6)
+
+	_, found7 := yvp.Read("someNonExistingVal")
+	assert.False(t, found7)
+
+	pb := New(yvp)
+	assert.Equal(t, []string{"one", "two"}, pb.ReadStrings("key7"))
+	assert.Equal(t, []string{"1", "2.5", "false"}, pb.ReadStrings("key8"))
+
+	strs, err := pb.ReadRequiredStrings("key7")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"one", "two"}, strs)
+}
+
+func TestYamlValuesProviderDump(t *testing.T) {
+	yvp, err := NewYAMLValuesProvider(strings.NewReader(yamlInput))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	writeBuf := &bytes.Buffer{}
+	err = yvp.Dump(writeBuf)
+	assert.NoError(t, err)
+
+	actualDumpValue := writeBuf.String()
+	assert.Contains(t, actualDumpValue, `"key1":"val1"`)
+	assert.Contains(t, actualDumpValue, `"key2":2`)
+	assert.Contains(t, actualDumpValue, `"key4":null`)
+	assert.Contains(t, actualDumpValue, `"key7":["one","two"]`)
+}
+
+func TestYamlValuesProviderToKeyValues(t *testing.T) {
+	yvp, err := NewYAMLValuesProvider(strings.NewReader("color: red\nnumber: 2\nnested:\n  1: one\n"))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	assert.Equal(
+		t,
+		map[string]interface{}{
+			"color":  "red",
+			"number": 2,
+			"nested": map[string]interface{}{"1": "one"},
+		},
+		yvp.ToKeyValues(),
+	)
+}
+
+func TestYamlValuesProviderInComposite(t *testing.T) {
+	yvp, err := NewYAMLValuesProvider(strings.NewReader("name: John\nage: 33"))
+	assert.NoError(t, err)
+	if err != nil {
+		return
+	}
+
+	mvp := NewMapValuesProvider(map[string]interface{}{
+		"name": "Bob",
+	})
+
+	pb := New(NewValuesProviderComposite(mvp, yvp))
+	assert.Equal(t, "Bob", pb.ReadString("name", ""))
+	assert.Equal(t, 33, pb.ReadInt("age", 0))
+}
+
+func TestYamlValuesProviderFailures(t *testing.T) {
+	_, err := NewYAMLValuesProvider(failingReader{})
+	assert.EqualError(t, err, "some error")
+
+	_, err = NewYAMLValuesProvider(strings.NewReader("- one\n- two"))
+	assert.Error(t, err)
+
+	malformedInput := "0: [:!00 \xef"
+	assert.NotPanics(t, func() {
+		_, err = NewYAMLValuesProvider(strings.NewReader(malformedInput))
+	})
+	assert.Error(t, err)
+
+	assert.NotPanics(t, func() {
+		_, err = ParseYAMLFile([]byte(malformedInput))
+	})
+	assert.Error(t, err)
+}
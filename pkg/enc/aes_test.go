@@ -0,0 +1,44 @@
+package enc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptAESGCM(t *testing.T) {
+	key, err := GenerateAESKey()
+	require.NoError(t, err)
+	assert.Len(t, key, AESKeySize)
+
+	encrypted, err := EncryptAESGCM([]byte("some msg"), key)
+	require.NoError(t, err)
+	assert.NotContains(t, string(encrypted), "some msg")
+
+	encryptedAgain, err := EncryptAESGCM([]byte("some msg"), key)
+	require.NoError(t, err)
+	assert.NotEqual(t, encrypted, encryptedAgain)
+
+	decrypted, err := DecryptAESGCM(encrypted, key)
+	assert.NoError(t, err)
+	assert.Equal(t, "some msg", string(decrypted))
+
+	otherKey, err := GenerateAESKey()
+	require.NoError(t, err)
+	_, err = DecryptAESGCM(encrypted, otherKey)
+	assert.EqualError(t, err, "cipher: message authentication failed")
+
+	encrypted[len(encrypted)-1] ^= 1
+	_, err = DecryptAESGCM(encrypted, key)
+	assert.EqualError(t, err, "cipher: message authentication failed")
+
+	_, err = DecryptAESGCM([]byte("short"), key)
+	assert.EqualError(t, err, "encrypted data is too short")
+
+	_, err = EncryptAESGCM([]byte("some msg"), []byte("123"))
+	assert.EqualError(t, err, "crypto/aes: invalid key size 3")
+
+	_, err = DecryptAESGCM(encrypted, []byte("123"))
+	assert.EqualError(t, err, "crypto/aes: invalid key size 3")
+}
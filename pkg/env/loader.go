@@ -0,0 +1,482 @@
+package env
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/breathbath/go_utils/v3/pkg/errs"
+	"github.com/breathbath/go_utils/v3/pkg/types"
+)
+
+const (
+	envTag      = "env"
+	defaultTag  = "default"
+	requiredTag = "required"
+	sepTag      = "sep"
+	prefixTag   = "prefix"
+
+	defaultSeparator = ","
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	urlPtrType          = reflect.TypeOf(&url.URL{})
+	decimalType         = reflect.TypeOf(types.Decimal{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// LoadOptions defines how Loader reads env variables
+type LoadOptions struct {
+	// Prefix is added to all variable names e.g. APP_ gives APP_DB_PORT for the DB_PORT variable
+	Prefix string
+	// EmptyAsUnset treats variables with empty values as not set, so the default value is used
+	EmptyAsUnset bool
+	// Output is used by MustLoad to print errors and the usage table, os.Stderr is used if nil
+	Output io.Writer
+}
+
+// VarUsage describes an env variable of a struct loaded by Loader
+type VarUsage struct {
+	Name       string
+	Type       string
+	Default    string
+	HasDefault bool
+	Required   bool
+	// Set tells if the variable is currently set
+	Set bool
+}
+
+type envField struct {
+	name  string
+	field reflect.StructField
+	val   reflect.Value
+}
+
+/*
+Loader fills structs from env variables, fields are configured with tags e.g.
+
+	type DBConfig struct {
+		Host    string        `env:"HOST" default:"localhost"`
+		Port    int           `env:"PORT" required:"true"`
+		Timeout time.Duration `env:"TIMEOUT" default:"5s"`
+	}
+
+	type Config struct {
+		DB    DBConfig          `prefix:"DB_"`
+		Hosts []string          `env:"HOSTS" sep:";"`
+		Tags  map[string]string `env:"TAGS"`
+		API   *url.URL          `env:"API_URL"`
+	}
+
+env gives the variable name, fields without it are skipped except nested structs and pointers to structs which are
+read with the prefix from the prefix tag, so the Port field above is read from DB_PORT, nil pointers to nested structs
+are allocated by Load, recursive struct types are rejected, default is used if the variable is not set,
+required:"true" gives an error for a missing variable without default, sep is the separator of slice items and
+map pairs, "," is used if not set, map pairs are given as key=value.
+Besides basic types, time.Duration, *url.URL, types.Decimal and any encoding.TextUnmarshaler are supported,
+errors for all missing or invalid variables are collected and returned as one error
+*/
+type Loader struct {
+	opts LoadOptions
+}
+
+// NewLoader creates a loader with the given options
+func NewLoader(opts LoadOptions) *Loader {
+	return &Loader{opts: opts}
+}
+
+// Load fills the struct which target points to with env variables, see Loader
+func Load(target interface{}) error {
+	return NewLoader(LoadOptions{}).Load(target)
+}
+
+// MustLoad same as Load but prints the error with the usage table to os.Stderr and panics if loading fails
+func MustLoad(target interface{}) {
+	NewLoader(LoadOptions{}).MustLoad(target)
+}
+
+// WriteUsage outputs the usage table of the struct which target points to, see Loader.WriteUsage
+func WriteUsage(w io.Writer, target interface{}) error {
+	return NewLoader(LoadOptions{}).WriteUsage(w, target)
+}
+
+// Load fills the struct which target points to with env variables
+func (l *Loader) Load(target interface{}) error {
+	fields, err := l.fields(target, true)
+	if err != nil {
+		return err
+	}
+
+	errCont := errs.NewErrorContainer()
+	for _, f := range fields {
+		errCont.AddError(l.loadField(f))
+	}
+
+	return errCont.Result(" ")
+}
+
+// MustLoad same as Load but prints the error with the usage table to the output and panics if loading fails
+func (l *Loader) MustLoad(target interface{}) {
+	err := l.Load(target)
+	if err == nil {
+		return
+	}
+
+	output := l.opts.Output
+	if output == nil {
+		output = os.Stderr
+	}
+
+	_, _ = fmt.Fprintf(output, "%v\n\n", err)
+	_ = l.WriteUsage(output, target)
+
+	panic(err)
+}
+
+// Usage gives descriptions of all variables of the struct which target points to in the order of the fields
+func (l *Loader) Usage(target interface{}) ([]VarUsage, error) {
+	fields, err := l.fields(target, false)
+	if err != nil {
+		return nil, err
+	}
+
+	usages := make([]VarUsage, 0, len(fields))
+	for _, f := range fields {
+		defaultVal, hasDefault := f.field.Tag.Lookup(defaultTag)
+		_, isSet := l.lookup(f.name)
+		usages = append(usages, VarUsage{
+			Name:       f.name,
+			Type:       f.field.Type.String(),
+			Default:    defaultVal,
+			HasDefault: hasDefault,
+			Required:   isRequired(f.field),
+			Set:        isSet,
+		})
+	}
+
+	return usages, nil
+}
+
+/*
+WriteUsage outputs all variables of the struct which target points to as an aligned table e.g.
+
+	VARIABLE  TYPE           REQUIRED  DEFAULT    SET
+	DB_HOST   string         no        localhost  no
+	DB_PORT   int            yes       -          yes
+*/
+func (l *Loader) WriteUsage(w io.Writer, target interface{}) error {
+	usages, err := l.Usage(target)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, err = fmt.Fprintln(tw, "VARIABLE\tTYPE\tREQUIRED\tDEFAULT\tSET")
+	if err != nil {
+		return err
+	}
+
+	for _, usage := range usages {
+		defaultVal := "-"
+		if usage.HasDefault {
+			defaultVal = usage.Default
+		}
+
+		_, err = fmt.Fprintf(
+			tw,
+			"%s\t%s\t%s\t%s\t%s\n",
+			usage.Name,
+			usage.Type,
+			FormatYesNo(usage.Required),
+			defaultVal,
+			FormatYesNo(usage.Set),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
+
+// fields gives the variables of target, nil pointers to nested structs are allocated if allocate is true,
+// otherwise their fields are taken from new detached values, so Usage doesn't change the target
+func (l *Loader) fields(target interface{}, allocate bool) ([]envField, error) {
+	targetVal := reflect.ValueOf(target)
+	if targetVal.Kind() != reflect.Ptr || targetVal.IsNil() || targetVal.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("env load target should be a non nil pointer to a struct, %T given", target)
+	}
+
+	fc := &fieldsCollector{
+		allocate: allocate,
+		parents:  map[reflect.Type]bool{},
+		fields:   []envField{},
+	}
+	err := fc.collect(targetVal.Elem(), l.opts.Prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return fc.fields, nil
+}
+
+type fieldsCollector struct {
+	allocate bool
+	// parents are types of the structs which are being collected, they are tracked to reject recursive types
+	parents map[reflect.Type]bool
+	fields  []envField
+}
+
+func (fc *fieldsCollector) collect(structVal reflect.Value, prefix string) error {
+	structType := structVal.Type()
+	if fc.parents[structType] {
+		return fmt.Errorf("env load target has recursive type %s", structType)
+	}
+	fc.parents[structType] = true
+	defer delete(fc.parents, structType)
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := field.Tag.Get(envTag)
+		if name == "-" {
+			continue
+		}
+
+		if name == "" && isNestedStruct(field.Type) {
+			err := fc.collect(fc.nestedStruct(structVal.Field(i)), prefix+field.Tag.Get(prefixTag))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if name == "" {
+			continue
+		}
+
+		fc.fields = append(fc.fields, envField{name: prefix + name, field: field, val: structVal.Field(i)})
+	}
+
+	return nil
+}
+
+// nestedStruct gives the struct value of a nested struct field or of a pointer to struct field
+func (fc *fieldsCollector) nestedStruct(fieldVal reflect.Value) reflect.Value {
+	if fieldVal.Kind() != reflect.Ptr {
+		return fieldVal
+	}
+
+	if !fieldVal.IsNil() {
+		return fieldVal.Elem()
+	}
+
+	structPtr := reflect.New(fieldVal.Type().Elem())
+	if fc.allocate {
+		fieldVal.Set(structPtr)
+	}
+
+	return structPtr.Elem()
+}
+
+func isNestedStruct(fieldType reflect.Type) bool {
+	if fieldType.Kind() == reflect.Ptr && fieldType != urlPtrType {
+		fieldType = fieldType.Elem()
+	}
+
+	return fieldType.Kind() == reflect.Struct &&
+		fieldType != decimalType &&
+		!reflect.PtrTo(fieldType).Implements(textUnmarshalerType)
+}
+
+func isRequired(field reflect.StructField) bool {
+	return field.Tag.Get(requiredTag) == "true"
+}
+
+func (l *Loader) lookup(name string) (string, bool) {
+	val, found := os.LookupEnv(name)
+	if l.opts.EmptyAsUnset && val == "" {
+		return "", false
+	}
+
+	return val, found
+}
+
+func (l *Loader) loadField(f envField) error {
+	sep := defaultSeparator
+	if fieldSep, hasSep := f.field.Tag.Lookup(sepTag); hasSep && fieldSep != "" {
+		sep = fieldSep
+	}
+
+	val, found := l.lookup(f.name)
+	if found {
+		err := setValue(f.val, val, sep)
+		if err != nil {
+			return &VarError{Name: f.name, Err: err}
+		}
+		return nil
+	}
+
+	defaultVal, hasDefault := f.field.Tag.Lookup(defaultTag)
+	if !hasDefault {
+		if isRequired(f.field) {
+			return &VarError{Name: f.name, Err: ErrVarNotSet}
+		}
+		return nil
+	}
+
+	err := setValue(f.val, defaultVal, sep)
+	if err != nil {
+		return &VarError{Name: f.name, Err: fmt.Errorf("invalid default value: %v", err)}
+	}
+
+	return nil
+}
+
+func setValue(target reflect.Value, val, sep string) error {
+	switch target.Type() {
+	case durationType:
+		dur, err := time.ParseDuration(strings.TrimSpace(val))
+		if err != nil {
+			return fmt.Errorf("cannot convert %q to duration", val)
+		}
+		target.SetInt(int64(dur))
+		return nil
+	case urlPtrType:
+		parsedURL, err := url.Parse(strings.TrimSpace(val))
+		if err != nil || parsedURL.Scheme == "" || parsedURL.Host == "" {
+			return fmt.Errorf("%s is not a valid absolute url", val)
+		}
+		target.Set(reflect.ValueOf(parsedURL))
+		return nil
+	case decimalType:
+		dec := types.Decimal{}
+		err := dec.UnmarshalJSON([]byte(strings.TrimSpace(val)))
+		if err != nil || strings.TrimSpace(val) == "" {
+			return fmt.Errorf("cannot convert %q to decimal", val)
+		}
+		target.Set(reflect.ValueOf(dec))
+		return nil
+	}
+
+	if target.CanAddr() && target.Addr().Type().Implements(textUnmarshalerType) {
+		return target.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(val))
+	}
+
+	switch target.Kind() {
+	case reflect.Ptr:
+		ptr := reflect.New(target.Type().Elem())
+		err := setValue(ptr.Elem(), val, sep)
+		if err != nil {
+			return err
+		}
+		target.Set(ptr)
+		return nil
+	case reflect.Slice:
+		return setSliceValue(target, val, sep)
+	case reflect.Map:
+		return setMapValue(target, val, sep)
+	default:
+		return setScalarValue(target, val)
+	}
+}
+
+func setScalarValue(target reflect.Value, val string) error {
+	trimmedVal := strings.TrimSpace(val)
+	convErr := fmt.Errorf("cannot convert %q to %s", val, target.Type())
+
+	switch target.Kind() {
+	case reflect.String:
+		target.SetString(val)
+	case reflect.Bool:
+		boolVal, err := ParseBool(val)
+		if err != nil {
+			return err
+		}
+		target.SetBool(boolVal)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		intVal, err := strconv.ParseInt(trimmedVal, 10, target.Type().Bits())
+		if err != nil {
+			return convErr
+		}
+		target.SetInt(intVal)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		uintVal, err := strconv.ParseUint(trimmedVal, 10, target.Type().Bits())
+		if err != nil {
+			return convErr
+		}
+		target.SetUint(uintVal)
+	case reflect.Float32, reflect.Float64:
+		floatVal, err := strconv.ParseFloat(trimmedVal, target.Type().Bits())
+		if err != nil {
+			return convErr
+		}
+		target.SetFloat(floatVal)
+	default:
+		return fmt.Errorf("unsupported type %s", target.Type())
+	}
+
+	return nil
+}
+
+func setSliceValue(target reflect.Value, val, sep string) error {
+	items := []string{}
+	if strings.TrimSpace(val) != "" {
+		items = strings.Split(val, sep)
+	}
+
+	slice := reflect.MakeSlice(target.Type(), len(items), len(items))
+	for i, item := range items {
+		err := setValue(slice.Index(i), strings.TrimSpace(item), sep)
+		if err != nil {
+			return err
+		}
+	}
+	target.Set(slice)
+
+	return nil
+}
+
+func setMapValue(target reflect.Value, val, sep string) error {
+	mapType := target.Type()
+	mapVal := reflect.MakeMap(mapType)
+	if strings.TrimSpace(val) == "" {
+		target.Set(mapVal)
+		return nil
+	}
+
+	for _, pair := range strings.Split(val, sep) {
+		keyVal := strings.SplitN(pair, "=", 2)
+		if len(keyVal) != 2 || strings.TrimSpace(keyVal[0]) == "" {
+			return fmt.Errorf("invalid map item %q, key=value is expected", strings.TrimSpace(pair))
+		}
+
+		key := reflect.New(mapType.Key()).Elem()
+		err := setValue(key, strings.TrimSpace(keyVal[0]), sep)
+		if err != nil {
+			return err
+		}
+
+		elem := reflect.New(mapType.Elem()).Elem()
+		err = setValue(elem, strings.TrimSpace(keyVal[1]), sep)
+		if err != nil {
+			return err
+		}
+
+		mapVal.SetMapIndex(key, elem)
+	}
+	target.Set(mapVal)
+
+	return nil
+}
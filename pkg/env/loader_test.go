@@ -0,0 +1,210 @@
+package env
+
+import (
+	"bytes"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/breathbath/go_utils/v3/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type loaderDBConfig struct {
+	Host    string        `env:"HOST" default:"localhost"`
+	Port    int           `env:"PORT" required:"true"`
+	Timeout time.Duration `env:"TIMEOUT" default:"5s"`
+}
+
+type loaderConfig struct {
+	DB        loaderDBConfig    `prefix:"DB_"`
+	Hosts     []string          `env:"HOSTS" sep:";"`
+	Ports     []uint16          `env:"PORTS"`
+	Tags      map[string]string `env:"TAGS"`
+	Limits    map[string]int    `env:"LIMITS" default:"a=1,b=2"`
+	API       *url.URL          `env:"API_URL"`
+	Price     types.Decimal     `env:"PRICE"`
+	IP        net.IP            `env:"IP"`
+	StartedAt *time.Time        `env:"STARTED_AT"`
+	Debug     bool              `env:"DEBUG"`
+	Password  types.Secret      `env:"PASSWORD"`
+	Ratio     float32           `env:"RATIO" default:"0.5"`
+	Skipped   string            `env:"-"`
+	Untagged  string
+	internal  string
+}
+
+func TestLoad(t *testing.T) {
+	t.Setenv("DB_PORT", "5432")
+	t.Setenv("DB_TIMEOUT", "1m")
+	t.Setenv("HOSTS", "a.com; b.com")
+	t.Setenv("PORTS", "80,443")
+	t.Setenv("TAGS", "env=prod, team = core")
+	t.Setenv("API_URL", "https://example.com/api")
+	t.Setenv("PRICE", "10.25")
+	t.Setenv("IP", "127.0.0.1")
+	t.Setenv("STARTED_AT", "2020-01-02T03:04:05Z")
+	t.Setenv("DEBUG", "on")
+	t.Setenv("PASSWORD", "pass123")
+	t.Setenv("Untagged", "val")
+
+	cfg := loaderConfig{Skipped: "skipped"}
+	err := Load(&cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, loaderDBConfig{Host: "localhost", Port: 5432, Timeout: time.Minute}, cfg.DB)
+	assert.Equal(t, []string{"a.com", "b.com"}, cfg.Hosts)
+	assert.Equal(t, []uint16{80, 443}, cfg.Ports)
+	assert.Equal(t, map[string]string{"env": "prod", "team": "core"}, cfg.Tags)
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, cfg.Limits)
+	assert.Equal(t, "https://example.com/api", cfg.API.String())
+	assert.Equal(t, "10.25", cfg.Price.String())
+	assert.Equal(t, "127.0.0.1", cfg.IP.String())
+	assert.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), *cfg.StartedAt)
+	assert.True(t, cfg.Debug)
+	assert.Equal(t, "pass123", cfg.Password.Reveal())
+	assert.Equal(t, float32(0.5), cfg.Ratio)
+	assert.Equal(t, "skipped", cfg.Skipped)
+	assert.Equal(t, "", cfg.Untagged)
+}
+
+func TestLoadErrors(t *testing.T) {
+	t.Setenv("DB_TIMEOUT", "5")
+	t.Setenv("PORTS", "80,70000")
+	t.Setenv("TAGS", "env")
+	t.Setenv("API_URL", "example.com")
+	t.Setenv("PRICE", "ten")
+	t.Setenv("DEBUG", "maybe")
+
+	cfg := loaderConfig{}
+	err := Load(&cfg)
+	assert.EqualError(
+		t,
+		err,
+		`required env variable 'DB_PORT' is not set `+
+			`invalid env variable DB_TIMEOUT: cannot convert "5" to duration `+
+			`invalid env variable PORTS: cannot convert "70000" to uint16 `+
+			`invalid env variable TAGS: invalid map item "env", key=value is expected `+
+			`invalid env variable API_URL: example.com is not a valid absolute url `+
+			`invalid env variable PRICE: cannot convert "ten" to decimal `+
+			`invalid env variable DEBUG: cannot convert "maybe" to bool`,
+	)
+
+	err = Load(cfg)
+	assert.EqualError(t, err, "env load target should be a non nil pointer to a struct, env.loaderConfig given")
+
+	invalidDefault := struct {
+		Port int `env:"LOADER_PORT" default:"abc"`
+	}{}
+	err = Load(&invalidDefault)
+	assert.EqualError(t, err, `invalid env variable LOADER_PORT: invalid default value: cannot convert "abc" to int`)
+
+	unsupported := struct {
+		Ch chan int `env:"LOADER_CHAN"`
+	}{}
+	t.Setenv("LOADER_CHAN", "1")
+	err = Load(&unsupported)
+	assert.EqualError(t, err, "invalid env variable LOADER_CHAN: unsupported type chan int")
+}
+
+type loaderNode struct {
+	Name string      `env:"NAME"`
+	Next *loaderNode `prefix:"NEXT_"`
+}
+
+func TestLoadPointerToStructFields(t *testing.T) {
+	t.Setenv("REPLICA_PORT", "5433")
+
+	cfg := struct {
+		Primary *loaderDBConfig `prefix:"PRIMARY_"`
+		Replica *loaderDBConfig `prefix:"REPLICA_"`
+	}{
+		Primary: &loaderDBConfig{Port: 1},
+	}
+
+	usages, err := NewLoader(LoadOptions{}).Usage(&cfg)
+	require.NoError(t, err)
+	assert.Len(t, usages, 6)
+	assert.Equal(t, "REPLICA_PORT", usages[4].Name)
+	assert.True(t, usages[4].Set)
+	assert.Nil(t, cfg.Replica)
+
+	err = Load(&cfg)
+	assert.EqualError(t, err, "required env variable 'PRIMARY_PORT' is not set")
+	assert.Equal(t, &loaderDBConfig{Host: "localhost", Port: 1, Timeout: 5 * time.Second}, cfg.Primary)
+	assert.Equal(t, &loaderDBConfig{Host: "localhost", Port: 5433, Timeout: 5 * time.Second}, cfg.Replica)
+
+	err = Load(&loaderNode{})
+	assert.EqualError(t, err, "env load target has recursive type env.loaderNode")
+}
+
+func TestLoaderOptions(t *testing.T) {
+	t.Setenv("APP_DB_PORT", "5433")
+	t.Setenv("APP_DB_HOST", "")
+	t.Setenv("APP_HOSTS", "")
+
+	cfg := loaderConfig{}
+	err := NewLoader(LoadOptions{Prefix: "APP_", EmptyAsUnset: true}).Load(&cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "localhost", cfg.DB.Host)
+	assert.Equal(t, 5433, cfg.DB.Port)
+	assert.Nil(t, cfg.Hosts)
+
+	cfg = loaderConfig{}
+	err = NewLoader(LoadOptions{Prefix: "APP_"}).Load(&cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "", cfg.DB.Host)
+	assert.Equal(t, []string{}, cfg.Hosts)
+}
+
+func TestWriteUsage(t *testing.T) {
+	t.Setenv("DB_PORT", "5432")
+
+	cfg := struct {
+		DB    loaderDBConfig `prefix:"DB_"`
+		Hosts []string       `env:"HOSTS"`
+	}{}
+
+	buf := &bytes.Buffer{}
+	err := WriteUsage(buf, &cfg)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		`VARIABLE    TYPE           REQUIRED  DEFAULT    SET
+DB_HOST     string         no        localhost  no
+DB_PORT     int            yes       -          yes
+DB_TIMEOUT  time.Duration  no        5s         no
+HOSTS       []string       no        -          no
+`,
+		buf.String(),
+	)
+}
+
+func TestMustLoad(t *testing.T) {
+	cfg := struct {
+		Port int `env:"LOADER_PORT" required:"true"`
+	}{}
+
+	buf := &bytes.Buffer{}
+	loader := NewLoader(LoadOptions{Output: buf})
+	assert.PanicsWithError(t, "required env variable 'LOADER_PORT' is not set", func() {
+		loader.MustLoad(&cfg)
+	})
+	assert.Equal(
+		t,
+		`required env variable 'LOADER_PORT' is not set
+
+VARIABLE     TYPE  REQUIRED  DEFAULT  SET
+LOADER_PORT  int   yes       -        no
+`,
+		buf.String(),
+	)
+
+	t.Setenv("LOADER_PORT", "80")
+	assert.NotPanics(t, func() {
+		loader.MustLoad(&cfg)
+	})
+	assert.Equal(t, 80, cfg.Port)
+}
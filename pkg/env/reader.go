@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/breathbath/go_utils/v3/pkg/errs"
+	"github.com/breathbath/go_utils/v3/pkg/types"
 )
 
 func ReadEnv(name, defaultVal string) string {
@@ -92,3 +93,13 @@ func ReadEnvOrFail(name string) string {
 	errs.FailOnError(err)
 	return val
 }
+
+// ReadEnvSecret same as ReadEnv but gives types.Secret which is masked in all outputs
+func ReadEnvSecret(name, defaultVal string) types.Secret {
+	return types.NewSecret(ReadEnv(name, defaultVal))
+}
+
+// ReadEnvSecretOrFail same as ReadEnvOrFail but gives types.Secret which is masked in all outputs
+func ReadEnvSecretOrFail(name string) types.Secret {
+	return types.NewSecret(ReadEnvOrFail(name))
+}
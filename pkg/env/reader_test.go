@@ -1,6 +1,7 @@
 package env
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"testing"
@@ -82,6 +83,7 @@ func TestSuite(t *testing.T) {
 	t.Run("testReadEnvOrFailWithExistingEnv", testReadEnvOrFailWithExistingEnv)
 	t.Run("testReadEnvOrFailWithNonExistingEnv", testReadEnvOrFailWithNonExistingEnv)
 	t.Run("testReadEnvBool", testReadEnvBool)
+	t.Run("testReadEnvSecret", testReadEnvSecret)
 }
 
 func testReadEnv(t *testing.T) {
@@ -135,3 +137,15 @@ func testReadEnvOrFailWithNonExistingEnv(t *testing.T) {
 		ReadEnvOrFail("NON_EXISTING_ENV_STRING")
 	})
 }
+
+func testReadEnvSecret(t *testing.T) {
+	secret := ReadEnvSecret("SOME_ENV_STRING", "")
+	assert.Equal(t, "GoLangCode", secret.Reveal())
+	assert.Equal(t, "******", fmt.Sprint(secret))
+	assert.Equal(t, "default", ReadEnvSecret("NON_EXISTING_ENV_STRING", "default").Reveal())
+
+	assert.Equal(t, "GoLangCode", ReadEnvSecretOrFail("SOME_ENV_STRING").Reveal())
+	assert.PanicsWithValue(t, "required env variable 'NON_EXISTING_ENV_STRING' is not set", func() {
+		ReadEnvSecretOrFail("NON_EXISTING_ENV_STRING")
+	})
+}
@@ -0,0 +1,231 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/breathbath/go_utils/v3/pkg/errs"
+	"github.com/breathbath/go_utils/v3/pkg/types"
+)
+
+// ReaderOptions defines how Reader treats env variables
+type ReaderOptions struct {
+	// EmptyAsUnset treats variables with empty values e.g. PORT= as not set, so the default value is used
+	EmptyAsUnset bool
+}
+
+// ErrVarNotSet is the Err of VarError for a required env variable which is not set
+var ErrVarNotSet = errors.New("required env variable is not set")
+
+// VarError describes an env variable which is not set though required or cannot be converted to the expected type
+type VarError struct {
+	Name string
+	Err  error
+}
+
+func (ve *VarError) Error() string {
+	if errors.Is(ve.Err, ErrVarNotSet) {
+		return fmt.Sprintf("required env variable '%s' is not set", ve.Name)
+	}
+
+	return fmt.Sprintf("invalid env variable %s: %v", ve.Name, ve.Err)
+}
+
+func (ve *VarError) Unwrap() error {
+	return ve.Err
+}
+
+/*
+Reader reads env variables like ReadEnv* functions but doesn't hide malformed values behind the defaults,
+it returns the default value for a malformed variable and records the error, so all errors can be checked at once e.g.
+
+	r := env.NewReader(env.ReaderOptions{})
+	port := r.Int("PORT", 80)
+	debug := r.Bool("DEBUG", false)
+	if err := r.Err(); err != nil {
+		log.Fatal(err)
+	}
+*/
+type Reader struct {
+	opts   ReaderOptions
+	mx     sync.Mutex
+	errors []*VarError
+}
+
+// NewReader creates a strict env reader
+func NewReader(opts ReaderOptions) *Reader {
+	return &Reader{
+		opts:   opts,
+		errors: []*VarError{},
+	}
+}
+
+func (r *Reader) lookup(name string) (string, bool) {
+	val, found := os.LookupEnv(name)
+	if r.opts.EmptyAsUnset && val == "" {
+		return "", false
+	}
+
+	return val, found
+}
+
+func (r *Reader) addError(name string, err error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	r.errors = append(r.errors, &VarError{Name: name, Err: err})
+}
+
+// Errors gives errors of all malformed or missing required variables in the order they were read
+func (r *Reader) Errors() []*VarError {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	errors := make([]*VarError, len(r.errors))
+	copy(errors, r.errors)
+
+	return errors
+}
+
+// Err gives one error with messages of all malformed or missing required variables or nil if there are no errors
+func (r *Reader) Err() error {
+	errCont := errs.NewErrorContainer()
+	for _, varErr := range r.Errors() {
+		errCont.AddError(varErr)
+	}
+
+	return errCont.Result(" ")
+}
+
+// String gives the variable value or defaultVal if it's not set
+func (r *Reader) String(name, defaultVal string) string {
+	val, found := r.lookup(name)
+	if !found {
+		return defaultVal
+	}
+
+	return val
+}
+
+// RequiredString gives the variable value and records an error if it's not set or empty
+func (r *Reader) RequiredString(name string) string {
+	val, found := r.lookup(name)
+	if !found || val == "" {
+		r.addError(name, ErrVarNotSet)
+		return ""
+	}
+
+	return val
+}
+
+// RequiredSecret same as RequiredString but gives types.Secret which is masked in all outputs
+func (r *Reader) RequiredSecret(name string) types.Secret {
+	return types.NewSecret(r.RequiredString(name))
+}
+
+// Int gives the variable as int or defaultVal if it's not set or malformed
+func (r *Reader) Int(name string, defaultVal int) int {
+	val, found := r.lookup(name)
+	if !found {
+		return defaultVal
+	}
+
+	intVal, err := strconv.Atoi(strings.TrimSpace(val))
+	if err != nil {
+		r.addError(name, fmt.Errorf("cannot convert %q to int", val))
+		return defaultVal
+	}
+
+	return intVal
+}
+
+// Int64 gives the variable as int64 or defaultVal if it's not set or malformed, hex and octal prefixes are supported
+// as in ReadEnvInt64
+func (r *Reader) Int64(name string, defaultVal int64) int64 {
+	val, found := r.lookup(name)
+	if !found {
+		return defaultVal
+	}
+
+	intVal, err := strconv.ParseInt(strings.TrimSpace(val), 0, 64)
+	if err != nil {
+		r.addError(name, fmt.Errorf("cannot convert %q to int64", val))
+		return defaultVal
+	}
+
+	return intVal
+}
+
+// Float gives the variable as float64 or defaultVal if it's not set or malformed
+func (r *Reader) Float(name string, defaultVal float64) float64 {
+	val, found := r.lookup(name)
+	if !found {
+		return defaultVal
+	}
+
+	floatVal, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+	if err != nil {
+		r.addError(name, fmt.Errorf("cannot convert %q to float", val))
+		return defaultVal
+	}
+
+	return floatVal
+}
+
+// Bool gives the variable as bool or defaultVal if it's not set or malformed, see ParseBool for accepted values
+func (r *Reader) Bool(name string, defaultVal bool) bool {
+	val, found := r.lookup(name)
+	if !found {
+		return defaultVal
+	}
+
+	boolVal, err := ParseBool(val)
+	if err != nil {
+		r.addError(name, err)
+		return defaultVal
+	}
+
+	return boolVal
+}
+
+// Duration gives the variable as time.Duration e.g. 1m30s or defaultVal if it's not set or malformed
+func (r *Reader) Duration(name string, defaultVal time.Duration) time.Duration {
+	val, found := r.lookup(name)
+	if !found {
+		return defaultVal
+	}
+
+	dur, err := time.ParseDuration(strings.TrimSpace(val))
+	if err != nil {
+		r.addError(name, fmt.Errorf("cannot convert %q to duration", val))
+		return defaultVal
+	}
+
+	return dur
+}
+
+// ParseBool converts case insensitive true/false, 1/0, yes/no and on/off to bool
+func ParseBool(val string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(val)) {
+	case "true", "1", "yes", "on":
+		return true, nil
+	case "false", "0", "no", "off":
+		return false, nil
+	default:
+		return false, fmt.Errorf("cannot convert %q to bool", val)
+	}
+}
+
+// FormatYesNo gives yes or no for flag e.g. in usage tables, ParseBool reads it back
+func FormatYesNo(flag bool) string {
+	if flag {
+		return "yes"
+	}
+
+	return "no"
+}
@@ -0,0 +1,92 @@
+package env
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReader(t *testing.T) {
+	t.Setenv("STRICT_ENV_STRING", "value")
+	t.Setenv("STRICT_ENV_EMPTY", "")
+	t.Setenv("STRICT_ENV_PORT", "80a")
+	t.Setenv("STRICT_ENV_INT", " 81 ")
+	t.Setenv("STRICT_ENV_INT64", "0x10")
+	t.Setenv("STRICT_ENV_FLOAT", "1.5")
+	t.Setenv("STRICT_ENV_BAD_FLOAT", "1,5")
+	t.Setenv("STRICT_ENV_BOOL", "Yes")
+	t.Setenv("STRICT_ENV_BAD_BOOL", "maybe")
+	t.Setenv("STRICT_ENV_DURATION", "1m30s")
+	t.Setenv("STRICT_ENV_BAD_DURATION", "90")
+
+	r := NewReader(ReaderOptions{})
+	assert.Equal(t, "value", r.String("STRICT_ENV_STRING", "default"))
+	assert.Equal(t, "", r.String("STRICT_ENV_EMPTY", "default"))
+	assert.Equal(t, "default", r.String("STRICT_ENV_MISSING", "default"))
+	assert.Equal(t, "value", r.RequiredString("STRICT_ENV_STRING"))
+	assert.Equal(t, "value", r.RequiredSecret("STRICT_ENV_STRING").Reveal())
+	assert.NoError(t, r.Err())
+
+	assert.Equal(t, 8080, r.Int("STRICT_ENV_PORT", 8080))
+	assert.Equal(t, 81, r.Int("STRICT_ENV_INT", 0))
+	assert.Equal(t, int64(16), r.Int64("STRICT_ENV_INT64", 0))
+	assert.Equal(t, int64(2), r.Int64("STRICT_ENV_PORT", 2))
+	assert.Equal(t, 1.5, r.Float("STRICT_ENV_FLOAT", 0))
+	assert.Equal(t, 2.5, r.Float("STRICT_ENV_BAD_FLOAT", 2.5))
+	assert.True(t, r.Bool("STRICT_ENV_BOOL", false))
+	assert.True(t, r.Bool("STRICT_ENV_BAD_BOOL", true))
+	assert.Equal(t, time.Second*90, r.Duration("STRICT_ENV_DURATION", 0))
+	assert.Equal(t, time.Second, r.Duration("STRICT_ENV_BAD_DURATION", time.Second))
+	assert.Equal(t, 3, r.Int("STRICT_ENV_EMPTY", 3))
+	assert.Equal(t, "", r.RequiredString("STRICT_ENV_MISSING"))
+
+	assert.EqualError(
+		t,
+		r.Err(),
+		`invalid env variable STRICT_ENV_PORT: cannot convert "80a" to int `+
+			`invalid env variable STRICT_ENV_PORT: cannot convert "80a" to int64 `+
+			`invalid env variable STRICT_ENV_BAD_FLOAT: cannot convert "1,5" to float `+
+			`invalid env variable STRICT_ENV_BAD_BOOL: cannot convert "maybe" to bool `+
+			`invalid env variable STRICT_ENV_BAD_DURATION: cannot convert "90" to duration `+
+			`invalid env variable STRICT_ENV_EMPTY: cannot convert "" to int `+
+			`required env variable 'STRICT_ENV_MISSING' is not set`,
+	)
+
+	varErrs := r.Errors()
+	assert.Len(t, varErrs, 7)
+	assert.Equal(t, "STRICT_ENV_PORT", varErrs[0].Name)
+	assert.EqualError(t, errors.Unwrap(varErrs[0]), `cannot convert "80a" to int`)
+	assert.True(t, errors.Is(varErrs[6], ErrVarNotSet))
+}
+
+func TestReaderEmptyAsUnset(t *testing.T) {
+	t.Setenv("STRICT_ENV_EMPTY", "")
+
+	r := NewReader(ReaderOptions{EmptyAsUnset: true})
+	assert.Equal(t, "default", r.String("STRICT_ENV_EMPTY", "default"))
+	assert.Equal(t, 3, r.Int("STRICT_ENV_EMPTY", 3))
+	assert.True(t, r.Bool("STRICT_ENV_EMPTY", true))
+	assert.NoError(t, r.Err())
+
+	r.RequiredString("STRICT_ENV_EMPTY")
+	assert.EqualError(t, r.Err(), "required env variable 'STRICT_ENV_EMPTY' is not set")
+}
+
+func TestParseBool(t *testing.T) {
+	for _, val := range []string{"true", "TRUE", "1", "yes", "Yes", "on", " ON "} {
+		boolVal, err := ParseBool(val)
+		assert.NoError(t, err, val)
+		assert.True(t, boolVal, val)
+	}
+
+	for _, val := range []string{"false", "False", "0", "no", "NO", "off", "Off"} {
+		boolVal, err := ParseBool(val)
+		assert.NoError(t, err, val)
+		assert.False(t, boolVal, val)
+	}
+
+	_, err := ParseBool("")
+	assert.EqualError(t, err, `cannot convert "" to bool`)
+}
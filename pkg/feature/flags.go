@@ -0,0 +1,390 @@
+package feature
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	options "github.com/breathbath/go_utils/v3/pkg/config"
+	"github.com/breathbath/go_utils/v3/pkg/enc"
+	errs2 "github.com/breathbath/go_utils/v3/pkg/errs"
+	io2 "github.com/breathbath/go_utils/v3/pkg/io"
+)
+
+const (
+	// LoggingTopic is used as topic for outputs of failed feature flag reloads
+	LoggingTopic = "feature_flags"
+	// DefaultPrefix is the option which contains the flag definitions e.g. {"features": {"new_checkout": true}}
+	DefaultPrefix = "features"
+)
+
+const (
+	enabledKey    = "enabled"
+	percentageKey = "percentage"
+	allowKey      = "allow"
+	denyKey       = "deny"
+)
+
+/*
+Flag is a feature flag definition, a flag is defined either by a bool e.g. "new_checkout": true or by an object e.g.
+
+	"beta_search": {"enabled": true, "percentage": 25, "allow": ["admin"], "deny": ["bot"]}
+
+enabled is true if not set, percentage enables the flag for the given share of subjects, allow and deny are
+lists of subject IDs or comma separated strings
+*/
+type Flag struct {
+	Name    string
+	Enabled bool
+	// Percentage is nil if the flag is enabled for all subjects
+	Percentage *float64
+	Allow      []string
+	Deny       []string
+}
+
+/*
+IsEnabledFor tells if the flag is enabled for the subject e.g. a user ID, the subject from Deny gets false,
+the subject from Allow gets true even if the flag is disabled, otherwise a disabled flag gets false and a flag
+with Percentage is enabled if the subject Bucket is less than Percentage, so the same subject always gets
+the same result and increasing the percentage keeps the flag enabled for already enabled subjects
+*/
+func (f *Flag) IsEnabledFor(subjectID string) bool {
+	if containsString(f.Deny, subjectID) {
+		return false
+	}
+
+	if containsString(f.Allow, subjectID) {
+		return true
+	}
+
+	if !f.Enabled {
+		return false
+	}
+
+	if f.Percentage == nil {
+		return true
+	}
+
+	if subjectID == "" {
+		return *f.Percentage >= 100
+	}
+
+	return Bucket(f.Name, subjectID) < *f.Percentage
+}
+
+// Bucket gives a stable number in the range [0, 100) for the flag and the subject based on enc.Checksum
+func Bucket(flagName, subjectID string) float64 {
+	checksum, err := strconv.ParseUint(enc.Checksum(flagName+":"+subjectID), 16, 32)
+	if err != nil {
+		return 0
+	}
+
+	return float64(checksum%10000) / 100
+}
+
+func containsString(items []string, needle string) bool {
+	for _, item := range items {
+		if item == needle {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseFlag converts a flag definition of a values provider to Flag
+func ParseFlag(name string, def interface{}) (Flag, error) {
+	flag := Flag{
+		Name:  name,
+		Allow: []string{},
+		Deny:  []string{},
+	}
+
+	objDef, isObject := def.(map[string]interface{})
+	if !isObject {
+		enabled, err := parseBool(def)
+		if err != nil {
+			return flag, fmt.Errorf("invalid feature flag %s: %v", name, err)
+		}
+		flag.Enabled = enabled
+		return flag, nil
+	}
+
+	for key := range objDef {
+		switch key {
+		case enabledKey, percentageKey, allowKey, denyKey:
+		default:
+			return flag, fmt.Errorf("invalid feature flag %s: unknown option %s", name, key)
+		}
+	}
+
+	flag.Enabled = true
+	if enabledDef, found := objDef[enabledKey]; found {
+		enabled, err := parseBool(enabledDef)
+		if err != nil {
+			return flag, fmt.Errorf("invalid feature flag %s: %v", name, err)
+		}
+		flag.Enabled = enabled
+	}
+
+	pb := options.New(options.NewMapValuesProvider(objDef))
+	if _, found := objDef[percentageKey]; found {
+		percentage, err := pb.ReadRequiredFloat64(percentageKey)
+		if err != nil || percentage < 0 || percentage > 100 {
+			return flag, fmt.Errorf("invalid feature flag %s: percentage %v is not in the range 0-100", name, objDef[percentageKey])
+		}
+		flag.Percentage = &percentage
+	}
+
+	var err error
+	if _, found := objDef[allowKey]; found {
+		flag.Allow, err = readSubjects(pb, allowKey, objDef[allowKey])
+		if err != nil {
+			return flag, fmt.Errorf("invalid feature flag %s: %v", name, err)
+		}
+	}
+
+	if _, found := objDef[denyKey]; found {
+		flag.Deny, err = readSubjects(pb, denyKey, objDef[denyKey])
+		if err != nil {
+			return flag, fmt.Errorf("invalid feature flag %s: %v", name, err)
+		}
+	}
+
+	return flag, nil
+}
+
+// readSubjects reads a list of subject IDs or splits a comma separated string of them
+func readSubjects(pb *options.ParameterBag, key string, def interface{}) ([]string, error) {
+	subjectsStr, isString := def.(string)
+	if !isString {
+		return pb.ReadRequiredStrings(key)
+	}
+
+	subjects := []string{}
+	for _, subject := range strings.Split(subjectsStr, ",") {
+		subject = strings.TrimSpace(subject)
+		if subject != "" {
+			subjects = append(subjects, subject)
+		}
+	}
+
+	return subjects, nil
+}
+
+func parseBool(val interface{}) (bool, error) {
+	switch typedVal := val.(type) {
+	case bool:
+		return typedVal, nil
+	case string:
+		boolVal, err := strconv.ParseBool(strings.TrimSpace(typedVal))
+		if err != nil {
+			return false, fmt.Errorf("cannot convert %s to bool", typedVal)
+		}
+		return boolVal, nil
+	default:
+		return false, fmt.Errorf("cannot convert %v to bool", val)
+	}
+}
+
+// ChangeNotifier is implemented by reloadable values providers e.g. options.ReloadableFileValuesProvider
+type ChangeNotifier interface {
+	OnChange(callback func(changedKeys []string))
+}
+
+/*
+Manager evaluates feature flags defined in a values provider, definitions are read on creation
+and on changes of the provider if it implements ChangeNotifier, overrides take precedence over definitions
+and are meant for tests, all methods are concurrency safe
+*/
+type Manager struct {
+	vp        options.ValuesProvider
+	prefix    string
+	mx        sync.RWMutex
+	flags     map[string]Flag
+	overrides map[string]bool
+}
+
+// NewManager reads flag definitions from the prefix option of vp, an empty prefix means all options of vp,
+// it fails if any definition is invalid
+func NewManager(vp options.ValuesProvider, prefix string) (*Manager, error) {
+	m := &Manager{
+		vp:        vp,
+		prefix:    prefix,
+		flags:     map[string]Flag{},
+		overrides: map[string]bool{},
+	}
+
+	err := m.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	if notifier, ok := vp.(ChangeNotifier); ok {
+		m.ReloadOn(notifier)
+	}
+
+	return m, nil
+}
+
+// ReloadOn reloads flag definitions when options of the prefix are changed in notifier, use it if the manager
+// reads a composite which contains a reloadable provider, failed reloads are logged and keep the current flags
+func (m *Manager) ReloadOn(notifier ChangeNotifier) {
+	notifier.OnChange(func(changedKeys []string) {
+		if !m.isAffected(changedKeys) {
+			return
+		}
+
+		err := m.Reload()
+		if err != nil {
+			io2.OutputError(err, LoggingTopic, "failed to reload feature flags")
+		}
+	})
+}
+
+func (m *Manager) isAffected(changedKeys []string) bool {
+	if m.prefix == "" {
+		return true
+	}
+
+	for _, key := range changedKeys {
+		if key == m.prefix || strings.HasPrefix(key, m.prefix+options.KeySeparator) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Reload reads flag definitions from the values provider, the current flags are kept if any definition is invalid
+func (m *Manager) Reload() error {
+	defs, err := m.readDefinitions()
+	if err != nil {
+		return err
+	}
+
+	flags := make(map[string]Flag, len(defs))
+	errs := errs2.NewErrorContainer()
+	for name, def := range defs {
+		flag, err := ParseFlag(name, def)
+		if err != nil {
+			errs.AddError(err)
+			continue
+		}
+		flags[name] = flag
+	}
+
+	err = errs.Result(" ")
+	if err != nil {
+		return err
+	}
+
+	m.mx.Lock()
+	m.flags = flags
+	m.mx.Unlock()
+
+	return nil
+}
+
+func (m *Manager) readDefinitions() (map[string]interface{}, error) {
+	if m.prefix == "" {
+		return m.vp.ToKeyValues(), nil
+	}
+
+	val, found, err := options.ReadValue(m.vp, m.prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found || val == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	defs, ok := val.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("feature flags option %s should be an object", m.prefix)
+	}
+
+	return defs, nil
+}
+
+// IsEnabled tells if the flag is enabled, flags with a percentage rollout are enabled only for 100%,
+// use IsEnabledFor to evaluate them for a subject, unknown flags are disabled
+func (m *Manager) IsEnabled(name string) bool {
+	return m.IsEnabledFor(name, "")
+}
+
+// IsEnabledFor tells if the flag is enabled for the subject e.g. a user ID, see Flag.IsEnabledFor
+func (m *Manager) IsEnabledFor(name, subjectID string) bool {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	if enabled, found := m.overrides[name]; found {
+		return enabled
+	}
+
+	flag, found := m.flags[name]
+	if !found {
+		return false
+	}
+
+	return flag.IsEnabledFor(subjectID)
+}
+
+// Flag gives the definition of the flag
+func (m *Manager) Flag(name string) (Flag, bool) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	flag, found := m.flags[name]
+
+	return flag, found
+}
+
+// Flags gives all flag definitions sorted by name
+func (m *Manager) Flags() []Flag {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	flags := make([]Flag, 0, len(m.flags))
+	for name := range m.flags {
+		flags = append(flags, m.flags[name])
+	}
+
+	sort.Slice(flags, func(i, j int) bool {
+		return flags[i].Name < flags[j].Name
+	})
+
+	return flags
+}
+
+// Override enables or disables the flag for all subjects regardless of its definition e.g. in tests,
+// the returned function restores the previous state
+func (m *Manager) Override(name string, enabled bool) (restore func()) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	prevEnabled, wasOverridden := m.overrides[name]
+	m.overrides[name] = enabled
+
+	return func() {
+		m.mx.Lock()
+		defer m.mx.Unlock()
+
+		if wasOverridden {
+			m.overrides[name] = prevEnabled
+			return
+		}
+		delete(m.overrides, name)
+	}
+}
+
+// ClearOverrides removes all overrides, so flags are evaluated by their definitions
+func (m *Manager) ClearOverrides() {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	m.overrides = map[string]bool{}
+}
@@ -0,0 +1,173 @@
+package feature
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	options "github.com/breathbath/go_utils/v3/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManagerIsEnabled(t *testing.T) {
+	vp, err := options.NewJSONValuesProvider(strings.NewReader(`{"features": {
+		"new_checkout": true,
+		"old_checkout": "false",
+		"beta_search": {"percentage": 30, "allow": ["admin"], "deny": "bot1, bot2"},
+		"full_rollout": {"percentage": 100},
+		"internal": {"enabled": false, "allow": ["admin"]}
+	}}`))
+	require.NoError(t, err)
+
+	m, err := NewManager(vp, DefaultPrefix)
+	require.NoError(t, err)
+
+	assert.True(t, m.IsEnabled("new_checkout"))
+	assert.False(t, m.IsEnabled("old_checkout"))
+	assert.False(t, m.IsEnabled("unknown"))
+	assert.False(t, m.IsEnabled("beta_search"))
+	assert.True(t, m.IsEnabled("full_rollout"))
+	assert.False(t, m.IsEnabled("internal"))
+
+	assert.True(t, m.IsEnabledFor("internal", "admin"))
+	assert.False(t, m.IsEnabledFor("internal", "user1"))
+	assert.True(t, m.IsEnabledFor("beta_search", "admin"))
+	assert.False(t, m.IsEnabledFor("beta_search", "bot2"))
+
+	enabledCount := 0
+	for i := 0; i < 1000; i++ {
+		subjectID := fmt.Sprintf("user%d", i)
+		isEnabled := m.IsEnabledFor("beta_search", subjectID)
+		assert.Equal(t, isEnabled, m.IsEnabledFor("beta_search", subjectID))
+		assert.Equal(t, Bucket("beta_search", subjectID) < 30, isEnabled)
+		if isEnabled {
+			enabledCount++
+		}
+	}
+	assert.InDelta(t, 300, enabledCount, 60)
+
+	flag, found := m.Flag("beta_search")
+	assert.True(t, found)
+	assert.True(t, flag.Enabled)
+	assert.Equal(t, 30.0, *flag.Percentage)
+	assert.Equal(t, []string{"admin"}, flag.Allow)
+	assert.Equal(t, []string{"bot1", "bot2"}, flag.Deny)
+
+	flagNames := []string{}
+	for _, flag := range m.Flags() {
+		flagNames = append(flagNames, flag.Name)
+	}
+	assert.Equal(t, []string{"beta_search", "full_rollout", "internal", "new_checkout", "old_checkout"}, flagNames)
+}
+
+func TestBucket(t *testing.T) {
+	bucket := Bucket("beta_search", "user1")
+	assert.Equal(t, bucket, Bucket("beta_search", "user1"))
+	assert.GreaterOrEqual(t, bucket, 0.0)
+	assert.Less(t, bucket, 100.0)
+}
+
+func TestManagerOverrides(t *testing.T) {
+	m, err := NewManager(options.NewMapValuesProvider(map[string]interface{}{
+		"new_checkout": false,
+	}), "")
+	require.NoError(t, err)
+
+	restore := m.Override("new_checkout", true)
+	assert.True(t, m.IsEnabled("new_checkout"))
+	assert.True(t, m.IsEnabledFor("new_checkout", "user1"))
+
+	restoreNested := m.Override("new_checkout", false)
+	assert.False(t, m.IsEnabled("new_checkout"))
+	restoreNested()
+	assert.True(t, m.IsEnabled("new_checkout"))
+	restore()
+	assert.False(t, m.IsEnabled("new_checkout"))
+
+	m.Override("unknown", true)
+	assert.True(t, m.IsEnabled("unknown"))
+	m.ClearOverrides()
+	assert.False(t, m.IsEnabled("unknown"))
+}
+
+func TestManagerInvalidDefinitions(t *testing.T) {
+	_, err := NewManager(options.NewMapValuesProvider(map[string]interface{}{
+		"features": map[string]interface{}{
+			"a": "maybe",
+		},
+	}), DefaultPrefix)
+	assert.EqualError(t, err, "invalid feature flag a: cannot convert maybe to bool")
+
+	testCases := []struct {
+		def           interface{}
+		expectedError string
+	}{
+		{
+			def:           map[string]interface{}{"percentage": 101},
+			expectedError: "invalid feature flag f: percentage 101 is not in the range 0-100",
+		},
+		{
+			def:           map[string]interface{}{"percentage": "half"},
+			expectedError: "invalid feature flag f: percentage half is not in the range 0-100",
+		},
+		{
+			def:           map[string]interface{}{"enabled": 1},
+			expectedError: "invalid feature flag f: cannot convert 1 to bool",
+		},
+		{
+			def:           map[string]interface{}{"percent": 10},
+			expectedError: "invalid feature flag f: unknown option percent",
+		},
+		{
+			def:           map[string]interface{}{"allow": map[string]interface{}{}},
+			expectedError: "invalid feature flag f: cannot convert value map[] to []string",
+		},
+	}
+
+	for _, testCase := range testCases {
+		_, err := ParseFlag("f", testCase.def)
+		assert.EqualError(t, err, testCase.expectedError)
+	}
+
+	_, err = NewManager(options.NewMapValuesProvider(map[string]interface{}{"features": true}), DefaultPrefix)
+	assert.EqualError(t, err, "feature flags option features should be an object")
+
+	m, err := NewManager(options.NewMapValuesProvider(map[string]interface{}{}), DefaultPrefix)
+	assert.NoError(t, err)
+	assert.Len(t, m.Flags(), 0)
+}
+
+func TestManagerReloadsOnChange(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "features.json")
+	startTime := time.Now().Add(-time.Hour)
+	writeFlagsFile(t, filePath, `{"features": {"new_checkout": false}, "color": "red"}`, startTime)
+
+	rfvp, err := options.NewReloadableFileValuesProvider(filePath, nil)
+	require.NoError(t, err)
+
+	m, err := NewManager(rfvp, DefaultPrefix)
+	require.NoError(t, err)
+	assert.False(t, m.IsEnabled("new_checkout"))
+
+	writeFlagsFile(t, filePath, `{"features": {"new_checkout": true}, "color": "red"}`, startTime.Add(time.Minute))
+	_, err = rfvp.Reload()
+	require.NoError(t, err)
+	assert.True(t, m.IsEnabled("new_checkout"))
+
+	writeFlagsFile(t, filePath, `{"features": {"new_checkout": "maybe"}, "color": "red"}`, startTime.Add(time.Minute*2))
+	_, err = rfvp.Reload()
+	require.NoError(t, err)
+	assert.True(t, m.IsEnabled("new_checkout"))
+}
+
+func writeFlagsFile(t *testing.T, filePath, content string, modTime time.Time) {
+	err := os.WriteFile(filePath, []byte(content), 0600)
+	require.NoError(t, err)
+
+	err = os.Chtimes(filePath, modTime, modTime)
+	require.NoError(t, err)
+}
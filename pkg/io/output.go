@@ -5,10 +5,21 @@ import (
 	"log"
 	"regexp"
 	"strings"
+
+	"github.com/breathbath/go_utils/v3/pkg/types"
 )
 
 var maxMessageLength = 5000
 
+// MaskedValue is shown instead of secret arguments in outputs
+const MaskedValue = types.SecretMask
+
+// Secret is implemented by values which should never get into outputs e.g. types.Secret,
+// such arguments are replaced with MaskedValue before they are formatted or passed to the logger
+type Secret interface {
+	Reveal() string
+}
+
 var logger Logger
 
 type Logger interface {
@@ -54,7 +65,7 @@ we also cut line separators because normally such messages are split by them as
 we also cut too long messages (more than maxMessageLength chars) because of the processing overhead for long outputs
 */
 func OutputError(err error, topic, msg string, args ...interface{}) {
-	msgToOutput := fmt.Sprintf(msg, args...)
+	msgToOutput := fmt.Sprintf(msg, maskSecrets(args)...)
 	finalOutput := ""
 	if msgToOutput == "" {
 		finalOutput = err.Error()
@@ -89,7 +100,20 @@ func OutputMessageType(messageType, topic, msg string, args ...interface{}) {
 		logger = DefaultLogger{}
 	}
 
-	logger.OutputMessageType(messageType, topic, msg, args...)
+	logger.OutputMessageType(messageType, topic, msg, maskSecrets(args)...)
+}
+
+func maskSecrets(args []interface{}) []interface{} {
+	maskedArgs := make([]interface{}, len(args))
+	for i, arg := range args {
+		if _, isSecret := arg.(Secret); isSecret {
+			maskedArgs[i] = MaskedValue
+			continue
+		}
+		maskedArgs[i] = arg
+	}
+
+	return maskedArgs
 }
 
 func GenerateMessage(eventType, message, topic string) string {
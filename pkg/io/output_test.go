@@ -119,3 +119,27 @@ func TestCustomLogger(t *testing.T) {
 	OutputInfo("some top", "some msg %s", "here")
 	assert.Equal(t, "INFO-some top-some msg here", l.input)
 }
+
+type secretMock struct {
+	value string
+}
+
+func (sm secretMock) Reveal() string {
+	return sm.value
+}
+
+func (sm secretMock) String() string {
+	return sm.value
+}
+
+func TestSecretsAreMasked(t *testing.T) {
+	l := &mockedLogger{}
+	SetLogger(l)
+	defer SetLogger(DefaultLogger{})
+
+	OutputInfo("some top", "password %s, user %s", secretMock{value: "pass123"}, "admin")
+	assert.Equal(t, "INFO-some top-password ******, user admin", l.input)
+
+	OutputError(errors.New("some error"), "some top", "password %v", &secretMock{value: "pass123"})
+	assert.Equal(t, "ERROR-some top-some error, password ******", l.input)
+}
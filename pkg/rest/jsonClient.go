@@ -12,8 +12,12 @@ import (
 	"time"
 
 	io2 "github.com/breathbath/go_utils/v3/pkg/io"
+	"github.com/breathbath/go_utils/v3/pkg/types"
 )
 
+// sensitiveHeaders are masked in verbose outputs of requests
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "X-Api-Key"}
+
 type RequestContext struct {
 	TargetURL    string
 	Method       string
@@ -22,23 +26,69 @@ type RequestContext struct {
 	ProxyURL     string
 	LoggingTopic string
 	IsVerbose    bool
+	// HideResponseBody skips the response body in logs e.g. if it contains secrets
+	HideResponseBody bool
 }
 
+// String describes the request for logs, values of sensitive headers and proxy passwords are masked
 func (rc *RequestContext) String() string {
 	proxyURL := ""
 	if rc.ProxyURL != "" {
-		proxyURL = ", proxy: " + rc.ProxyURL
+		proxyURL = ", proxy: " + redactURL(rc.ProxyURL)
+	}
+
+	headers := make(map[string]string, len(rc.Headers))
+	for name, value := range rc.Headers {
+		if isSensitiveHeader(name) {
+			value = types.SecretMask
+		}
+		headers[name] = value
 	}
+
 	return fmt.Sprintf(
 		"Request: method %s, url '%s', body '%s', headers: %v%s",
 		rc.Method,
 		rc.TargetURL,
 		rc.Body,
-		rc.Headers,
+		headers,
 		proxyURL,
 	)
 }
 
+func isSensitiveHeader(name string) bool {
+	for _, sensitiveHeader := range sensitiveHeaders {
+		if strings.EqualFold(name, sensitiveHeader) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func redactURL(rawURL string) string {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || parsedURL.User == nil {
+		return rawURL
+	}
+
+	return parsedURL.Redacted()
+}
+
+// dumpRequest gives the raw request with masked sensitive headers, the body of req stays readable
+func dumpRequest(req *http.Request) string {
+	dumpReq := req.Clone(req.Context())
+	for name := range dumpReq.Header {
+		if isSensitiveHeader(name) {
+			dumpReq.Header.Set(name, types.SecretMask)
+		}
+	}
+
+	dump, _ := httputil.DumpRequest(dumpReq, true)
+	req.Body = dumpReq.Body
+
+	return string(dump)
+}
+
 type JSONClient struct{}
 
 func NewJSONClient() *JSONClient {
@@ -77,8 +127,7 @@ func (jc *JSONClient) CallAPI(ctx context.Context, requestContext *RequestContex
 	client := http.Client{Transport: transport}
 
 	if requestContext.IsVerbose {
-		dump, _ := httputil.DumpRequest(req, true)
-		io2.OutputInfo(requestContext.LoggingTopic, "Input context: %s, raw request: %s", requestContext.String(), string(dump))
+		io2.OutputInfo(requestContext.LoggingTopic, "Input context: %s, raw request: %s", requestContext.String(), dumpRequest(req))
 	} else {
 		// hiding details here for security reasons (no sensitive data in logs)
 		io2.OutputInfo(requestContext.LoggingTopic, "Calling api")
@@ -109,7 +158,11 @@ func (jc *JSONClient) CallAPI(ctx context.Context, requestContext *RequestContex
 		return []byte{}, resp, fmt.Errorf("reading of the request body failed with error: %v, status: %d", err, resp.StatusCode)
 	}
 
-	io2.OutputInfo(requestContext.LoggingTopic, "Got response: '%s', status code: '%d'", string(respBody), resp.StatusCode)
+	if requestContext.HideResponseBody {
+		io2.OutputInfo(requestContext.LoggingTopic, "Got response of %d bytes, status code: '%d'", len(respBody), resp.StatusCode)
+	} else {
+		io2.OutputInfo(requestContext.LoggingTopic, "Got response: '%s', status code: '%d'", string(respBody), resp.StatusCode)
+	}
 
 	err = ValidateResponse(requestContext.TargetURL, resp, respBody)
 	return respBody, resp, err
@@ -0,0 +1,75 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// SecretMask is shown instead of secret values in all outputs
+const SecretMask = "******"
+
+/*
+Secret keeps a sensitive string e.g. a password or an API token which should never get into logs or dumps,
+String, GoString, Format and MarshalJSON give SecretMask, so the value is reachable only through Reveal, e.g.
+
+	password := env.ReadEnvSecret("DB_PASSWORD", "")
+	log.Printf("connecting with %v", password) // connecting with ******
+	dsn := fmt.Sprintf("user:%s@/db", password.Reveal())
+
+note that fmt can't call methods of unexported struct fields, so keep secrets in exported fields
+if the containing struct is printed, also a marshaled Secret can't be read back, because MarshalJSON and MarshalText
+give SecretMask while UnmarshalText reads the given text, so persist the Reveal result instead of the Secret
+*/
+type Secret struct {
+	value string
+}
+
+// NewSecret wraps a sensitive value
+func NewSecret(value string) Secret {
+	return Secret{value: value}
+}
+
+// Reveal gives the original value
+func (s Secret) Reveal() string {
+	return s.value
+}
+
+// IsEmpty tells if the secret value is empty e.g. because it's not set
+func (s Secret) IsEmpty() bool {
+	return s.value == ""
+}
+
+func (s Secret) String() string {
+	return SecretMask
+}
+
+func (s Secret) GoString() string {
+	return SecretMask
+}
+
+// Format outputs SecretMask for all verbs, %q gives a quoted mask
+func (s Secret) Format(f fmt.State, verb rune) {
+	if verb == 'q' {
+		_, _ = io.WriteString(f, strconv.Quote(SecretMask))
+		return
+	}
+
+	_, _ = io.WriteString(f, SecretMask)
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(SecretMask)
+}
+
+// MarshalText gives SecretMask, so a Secret saved as text is loaded back as SecretMask and not as the original value
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(SecretMask), nil
+}
+
+// UnmarshalText reads the original value e.g. from json strings or env variables
+func (s *Secret) UnmarshalText(text []byte) error {
+	s.value = string(text)
+	return nil
+}
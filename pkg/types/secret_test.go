@@ -0,0 +1,52 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type secretHolder struct {
+	User     string
+	Password Secret
+}
+
+func TestSecretOutputs(t *testing.T) {
+	secret := NewSecret("pass123")
+
+	assert.Equal(t, "pass123", secret.Reveal())
+	assert.False(t, secret.IsEmpty())
+	assert.True(t, Secret{}.IsEmpty())
+
+	assert.Equal(t, SecretMask, secret.String())
+	assert.Equal(t, SecretMask, secret.GoString())
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%x", "%d", "%10s"} {
+		assert.Equal(t, SecretMask, fmt.Sprintf(format, secret), format)
+		assert.Equal(t, SecretMask, fmt.Sprintf(format, &secret), format)
+	}
+	assert.Equal(t, `"******"`, fmt.Sprintf("%q", secret))
+
+	holder := secretHolder{User: "admin", Password: secret}
+	assert.Equal(t, "{admin ******}", fmt.Sprintf("%v", holder))
+	assert.Equal(t, "{User:admin Password:******}", fmt.Sprintf("%+v", holder))
+	assert.Equal(t, `types.secretHolder{User:"admin", Password:******}`, fmt.Sprintf("%#v", holder))
+	assert.Equal(t, "[******]", fmt.Sprint([]Secret{secret}))
+
+	jsonBytes, err := json.Marshal(holder)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"User":"admin","Password":"******"}`, string(jsonBytes))
+}
+
+func TestSecretUnmarshal(t *testing.T) {
+	holder := secretHolder{}
+	err := json.Unmarshal([]byte(`{"User":"admin","Password":"pass123"}`), &holder)
+	assert.NoError(t, err)
+	assert.Equal(t, "pass123", holder.Password.Reveal())
+
+	secret := Secret{}
+	err = secret.UnmarshalText([]byte("token"))
+	assert.NoError(t, err)
+	assert.Equal(t, "token", secret.Reveal())
+}